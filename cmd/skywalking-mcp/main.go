@@ -30,7 +30,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/apache/skywalking-mcp/internal/oap"
 	"github.com/apache/skywalking-mcp/internal/swmcp"
+	"github.com/apache/skywalking-mcp/internal/tools"
 )
 
 var (
@@ -43,6 +45,22 @@ var (
 		Short:   "Apache SkyWalking MCP Server.",
 		Long:    `This is a server that implements the MCP protocol for Apache SkyWalking.`,
 		Version: fmt.Sprintf("Version: %s\nCommit: %s\nBuild Date: %s", version, commit, date),
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// flags are parsed at this point, configuration errors do not need the usage
+			cmd.SilenceUsage = true
+			cfg, err := swmcp.LoadConfig()
+			if err != nil {
+				return err
+			}
+
+			// the log level and timezone may be set in the configuration file
+			slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+				Level: parseLogLevel(viper.GetString("log-level")),
+			})))
+			initTimezone(cfg.Timezone)
+
+			return swmcp.Configure(cfg)
+		},
 	}
 )
 
@@ -57,31 +75,61 @@ func init() {
 	rootCmd.SetVersionTemplate("{{.Short}}\n{{.Version}}\n")
 
 	// Add global Flags
+	rootCmd.PersistentFlags().String("config", "", "Path to a YAML, TOML or JSON configuration file")
+	rootCmd.PersistentFlags().String("profile", "", "Name of the profile of the configuration file to use")
+	rootCmd.PersistentFlags().StringSlice("tool-groups", nil,
+		"Groups of tools to enable: metadata, trace, metrics, log, mqe, alarm, topology, event, profiling. Defaults to all groups")
+	rootCmd.PersistentFlags().Duration("default-duration", tools.DefaultDuration*time.Minute,
+		"Time range of metric, log, alarm, event and topology queries that specify none")
+	rootCmd.PersistentFlags().Duration("default-trace-duration", tools.DefaultTraceDuration, "Time range of trace queries that specify none")
+	rootCmd.PersistentFlags().Duration("cold-trace-lookback", tools.DefaultColdTraceLookback,
+		"Longest time range searched in cold storage for traces missing from hot storage, 0 disables the search")
 	rootCmd.PersistentFlags().String("sw-url", "", "Specify the OAP URL to connect to (e.g. http://localhost:12800)")
 	rootCmd.PersistentFlags().String("log-level", "info", "Logging level (debug, info, warn, error)")
 	rootCmd.PersistentFlags().Bool("read-only", false, "Restrict the server to read-only operations")
 	rootCmd.PersistentFlags().Bool("log-command", false, "When true, log commands to the log file")
 	rootCmd.PersistentFlags().String("log-file", "", "Path to log file")
 	rootCmd.PersistentFlags().String("timezone", "", "Timezone for time calculations (e.g. Asia/Shanghai, UTC, America/New_York). Defaults to local system timezone")
+	rootCmd.PersistentFlags().String("sw-username", "", "Username for HTTP basic authentication to OAP")
+	rootCmd.PersistentFlags().String("sw-password", "", "Password for HTTP basic authentication to OAP")
+	rootCmd.PersistentFlags().String("sw-token", "", "Bearer token for authentication to OAP, takes precedence over basic authentication")
+	rootCmd.PersistentFlags().StringArray("sw-header", nil, "Custom header sent with every OAP request, in the form \"Name: value\" (repeatable)")
+	rootCmd.PersistentFlags().String("sw-ca-file", "", "PEM encoded CA bundle to verify the OAP certificate")
+	rootCmd.PersistentFlags().String("sw-cert-file", "", "PEM encoded client certificate for mutual TLS with OAP")
+	rootCmd.PersistentFlags().String("sw-key-file", "", "PEM encoded client key for mutual TLS with OAP")
+	rootCmd.PersistentFlags().Bool("sw-insecure-skip-verify", false, "Skip verification of the OAP certificate (insecure)")
+	rootCmd.PersistentFlags().Duration("sw-timeout", oap.DefaultTimeout, "Timeout of a single request to OAP")
+	rootCmd.PersistentFlags().Int("sw-retries", oap.DefaultMaxRetries, "Number of retries of OAP queries failing with 5xx responses or reset connections")
+	rootCmd.PersistentFlags().Duration("sw-retry-backoff", oap.DefaultRetryBackoff, "Delay before the first retry of an OAP query, doubled on every further retry")
 
 	// Bind flag to viper
+	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	_ = viper.BindPFlag("tool-groups", rootCmd.PersistentFlags().Lookup("tool-groups"))
+	_ = viper.BindPFlag("default-duration", rootCmd.PersistentFlags().Lookup("default-duration"))
+	_ = viper.BindPFlag("default-trace-duration", rootCmd.PersistentFlags().Lookup("default-trace-duration"))
+	_ = viper.BindPFlag("cold-trace-lookback", rootCmd.PersistentFlags().Lookup("cold-trace-lookback"))
 	_ = viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("sw-url"))
 	_ = viper.BindPFlag("log-level", rootCmd.PersistentFlags().Lookup("log-level"))
 	_ = viper.BindPFlag("read-only", rootCmd.PersistentFlags().Lookup("read-only"))
 	_ = viper.BindPFlag("log-command", rootCmd.PersistentFlags().Lookup("log-command"))
 	_ = viper.BindPFlag("log-file", rootCmd.PersistentFlags().Lookup("log-file"))
 	_ = viper.BindPFlag("timezone", rootCmd.PersistentFlags().Lookup("timezone"))
-
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: parseLogLevel(viper.GetString("log-level")),
-	})))
+	_ = viper.BindPFlag("username", rootCmd.PersistentFlags().Lookup("sw-username"))
+	_ = viper.BindPFlag("password", rootCmd.PersistentFlags().Lookup("sw-password"))
+	_ = viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("sw-token"))
+	_ = viper.BindPFlag("header", rootCmd.PersistentFlags().Lookup("sw-header"))
+	_ = viper.BindPFlag("ca-file", rootCmd.PersistentFlags().Lookup("sw-ca-file"))
+	_ = viper.BindPFlag("cert-file", rootCmd.PersistentFlags().Lookup("sw-cert-file"))
+	_ = viper.BindPFlag("key-file", rootCmd.PersistentFlags().Lookup("sw-key-file"))
+	_ = viper.BindPFlag("insecure-skip-verify", rootCmd.PersistentFlags().Lookup("sw-insecure-skip-verify"))
+	_ = viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("sw-timeout"))
+	_ = viper.BindPFlag("retries", rootCmd.PersistentFlags().Lookup("sw-retries"))
+	_ = viper.BindPFlag("retry-backoff", rootCmd.PersistentFlags().Lookup("sw-retry-backoff"))
 
 	_, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Initialize global timezone
-	initTimezone()
-
 	// Add subcommands
 	rootCmd.AddCommand(swmcp.NewStdioServer())
 	rootCmd.AddCommand(swmcp.NewSSEServer())
@@ -95,8 +143,7 @@ func main() {
 	}
 }
 
-func initTimezone() {
-	tz := viper.GetString("timezone")
+func initTimezone(tz string) {
 	if tz != "" {
 		loc, err := time.LoadLocation(tz)
 		if err != nil {
@@ -0,0 +1,143 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package auth authenticates the clients of the HTTP transports and restricts the tools they can call.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrUnauthenticated is returned when a request carries no or unknown credentials.
+// Authenticators wrap it to report why credentials they recognize are invalid, e.g. an expired JWT.
+var ErrUnauthenticated = errors.New("missing or invalid bearer token")
+
+// Principal is an authenticated client.
+type Principal struct {
+	// Name identifies the client in logs, e.g. the token name or the JWT subject
+	Name string
+
+	// Tools is the allowlist of tools the client can call, empty means all tools
+	Tools []string
+}
+
+// AllowsTool reports whether the principal is allowed to call the tool.
+func (p *Principal) AllowsTool(name string) bool {
+	return len(p.Tools) == 0 || slices.Contains(p.Tools, name)
+}
+
+// Authenticator verifies the bearer token of a request.
+type Authenticator interface {
+	Authenticate(token string) (*Principal, error)
+}
+
+// Chain tries the authenticators in order and returns the first principal found.
+type Chain []Authenticator
+
+// Authenticate implements Authenticator.
+func (c Chain) Authenticate(token string) (*Principal, error) {
+	err := ErrUnauthenticated
+	for _, authenticator := range c {
+		principal, authErr := authenticator.Authenticate(token)
+		if authErr == nil {
+			return principal, nil
+		}
+		if authErr != ErrUnauthenticated { //nolint:errorlint // keep the most specific reason
+			err = authErr
+		}
+	}
+	return nil, err
+}
+
+type principalKey struct{}
+
+// WithPrincipal adds the authenticated principal to the context.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal, or nil if the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// bearerToken extracts the token of the Authorization header.
+func bearerToken(req *http.Request) string {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Middleware rejects requests without valid credentials with 401 Unauthorized,
+// and adds the principal of authenticated requests to the request context.
+func Middleware(authenticator Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := bearerToken(req)
+		if token == "" {
+			unauthorized(w, ErrUnauthenticated)
+			return
+		}
+		principal, err := authenticator.Authenticate(token)
+		if err != nil {
+			unauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(WithPrincipal(req.Context(), principal)))
+	})
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", "invalid_token"))
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
+
+// ToolFilter hides the tools the principal of the request is not allowed to call.
+func ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil || len(principal.Tools) == 0 {
+		return tools
+	}
+	allowed := make([]mcp.Tool, 0, len(principal.Tools))
+	for _, tool := range tools {
+		if principal.AllowsTool(tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// ToolMiddleware rejects calls to tools the principal of the request is not allowed to call.
+func ToolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		principal := PrincipalFromContext(ctx)
+		if principal != nil && !principal.AllowsTool(request.Params.Name) {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %s is not allowed for %s", request.Params.Name, principal.Name)), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,321 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerance applied to the exp and nbf claims.
+const clockSkew = time.Minute
+
+// minRSAKeyBits is the smallest RSA modulus accepted in the JWKS file.
+const minRSAKeyBits = 2048
+
+// jwk is a public key of the JWKS file.
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// JWTAuthenticator authenticates JWTs signed by a key of a local JWKS file.
+// The optional "tools" claim, a list or a space separated string, is the allowlist of tools.
+type JWTAuthenticator struct {
+	keys     []jwk
+	issuer   string
+	audience string
+}
+
+// NewJWTAuthenticator loads the public keys of a JWKS file, RSA, EC and Ed25519 keys are supported.
+// If issuer or audience is not empty, the iss or aud claim of the tokens must match it.
+func NewJWTAuthenticator(jwksFile, issuer, audience string) (*JWTAuthenticator, error) {
+	data, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var jwks struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make([]jwk, 0, len(jwks.Keys))
+	for i := range jwks.Keys {
+		raw := &jwks.Keys[i]
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key #%d in JWKS file: %w", i+1, err)
+		}
+		keys = append(keys, jwk{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", jwksFile)
+	}
+	return &JWTAuthenticator{keys: keys, issuer: issuer, audience: audience}, nil
+}
+
+// rawJWK holds the members of a JWK needed to decode its public key, other members such as x5c are ignored.
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWK decodes the public key of a JWK.
+func parseJWK(raw *rawJWK) (crypto.PublicKey, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key of %d bits is too short, at least %d bits are required", n.BitLen(), minRSAKeyBits)
+		}
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch raw.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := decodeBigInt(raw.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(raw.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", raw.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter %q", s)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// stringList is a claim that is either a string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = strings.Fields(s)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// claims are the registered claims checked by JWTAuthenticator, plus the tools allowlist.
+type claims struct {
+	Subject   string     `json:"sub"`
+	Issuer    string     `json:"iss"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *int64     `json:"exp"`
+	NotBefore *int64     `json:"nbf"`
+	Tools     stringList `json:"tools"`
+}
+
+// Authenticate implements Authenticator.
+func (a *JWTAuthenticator) Authenticate(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnauthenticated
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrUnauthenticated
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if !a.verify(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return nil, fmt.Errorf("%w: invalid JWT signature", ErrUnauthenticated)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT claims", ErrUnauthenticated)
+	}
+	if err := a.validate(&c, time.Now()); err != nil {
+		return nil, err
+	}
+
+	name := c.Subject
+	if name == "" {
+		name = "JWT"
+	}
+	return &Principal{Name: name, Tools: c.Tools}, nil
+}
+
+// validate checks the time, issuer and audience claims.
+func (a *JWTAuthenticator) validate(c *claims, now time.Time) error {
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: JWT has no exp claim", ErrUnauthenticated)
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(clockSkew)) {
+		return fmt.Errorf("%w: JWT expired", ErrUnauthenticated)
+	}
+	if c.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*c.NotBefore, 0)) {
+		return fmt.Errorf("%w: JWT not valid yet", ErrUnauthenticated)
+	}
+	if a.issuer != "" && c.Issuer != a.issuer {
+		return fmt.Errorf("%w: unexpected JWT issuer %q", ErrUnauthenticated, c.Issuer)
+	}
+	if a.audience != "" && !slices.Contains(c.Audience, a.audience) {
+		return fmt.Errorf("%w: JWT audience does not include %q", ErrUnauthenticated, a.audience)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verify checks the signature with the keys matching the key ID and algorithm of the token.
+func (a *JWTAuthenticator) verify(alg, kid, signed string, signature []byte) bool {
+	for _, k := range a.keys {
+		if kid != "" && k.kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if verifySignature(alg, k.key, []byte(signed), signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature verifies a JWS signature, the "none" algorithm is never accepted.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) bool {
+	if alg == "EdDSA" {
+		edKey, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(edKey, signed, signature)
+	}
+	if len(alg) != 5 {
+		return false
+	}
+
+	var hashFunc crypto.Hash
+	var h hash.Hash
+	var curveBits int
+	switch alg[2:] {
+	case "256":
+		hashFunc, h, curveBits = crypto.SHA256, sha256.New(), 256
+	case "384":
+		hashFunc, h, curveBits = crypto.SHA384, sha512.New384(), 384
+	case "512":
+		hashFunc, h, curveBits = crypto.SHA512, sha512.New(), 521
+	default:
+		return false
+	}
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(key, hashFunc, digest, signature) == nil
+		case "PS":
+			return rsa.VerifyPSS(key, hashFunc, digest, signature, nil) == nil
+		}
+	case *ecdsa.PublicKey:
+		// ES256, ES384 and ES512 are bound to P-256, P-384 and P-521
+		bits := key.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		if alg[:2] != "ES" || bits != curveBits || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
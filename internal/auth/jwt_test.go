@@ -0,0 +1,338 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "skywalking-mcp"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// writeJWKS writes the keys to a JWKS file and returns its path.
+func writeJWKS(t *testing.T, keys ...map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "EC",
+		"kid": kid,
+		"alg": "ES256",
+		"crv": "P-256",
+		"x":   b64(key.X.FillBytes(make([]byte, 32))),
+		"y":   b64(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// signJWT builds a token with the header and claims, signed by sign.
+func signJWT(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := b64(headerJSON) + "." + b64(claimsJSON)
+	return signed + "." + b64(sign([]byte(signed)))
+}
+
+func rsaSigner(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func ecSigner(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := NewJWTAuthenticator(
+		writeJWKS(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)), testIssuer, testAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"sub":   "alice",
+			"iss":   testIssuer,
+			"aud":   testAudience,
+			"exp":   now.Add(time.Hour).Unix(),
+			"tools": "list_services query_traces",
+		}
+	}
+	withClaim := func(name string, value any) map[string]any {
+		c := validClaims()
+		c[name] = value
+		return c
+	}
+	rs256 := map[string]any{"alg": "RS256", "kid": "rsa"}
+	es256 := map[string]any{"alg": "ES256", "kid": "ec"}
+	signRSA := rsaSigner(t, rsaKey)
+	signEC := ecSigner(t, ecKey)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "valid RS256 token",
+			token: signJWT(t, rs256, validClaims(), signRSA),
+		},
+		{
+			name:  "valid ES256 token",
+			token: signJWT(t, es256, validClaims(), signEC),
+		},
+		{
+			name:  "valid token with audience list",
+			token: signJWT(t, rs256, withClaim("aud", []string{"other", testAudience}), signRSA),
+		},
+		{
+			name:    "alg none",
+			token:   signJWT(t, map[string]any{"alg": "none"}, validClaims(), func([]byte) []byte { return nil }),
+			wantErr: true,
+		},
+		{
+			// an HMAC keyed with the public key must not pass as a signature of that key
+			name: "alg HS256",
+			token: signJWT(t, map[string]any{"alg": "HS256", "kid": "rsa"}, validClaims(), func(signed []byte) []byte {
+				mac := hmac.New(sha256.New, rsaKey.N.Bytes())
+				mac.Write(signed)
+				return mac.Sum(nil)
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "RS256 signature presented as ES256",
+			token:   signJWT(t, map[string]any{"alg": "ES256", "kid": "rsa"}, validClaims(), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   signJWT(t, rs256, withClaim("exp", now.Add(-time.Hour).Unix()), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "no exp claim",
+			token:   signJWT(t, rs256, withClaim("exp", nil), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "not valid yet",
+			token:   signJWT(t, rs256, withClaim("nbf", now.Add(time.Hour).Unix()), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   signJWT(t, rs256, withClaim("aud", "other"), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			token:   signJWT(t, rs256, withClaim("iss", "https://evil.example.com"), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "unknown kid",
+			token:   signJWT(t, map[string]any{"alg": "RS256", "kid": "unknown"}, validClaims(), signRSA),
+			wantErr: true,
+		},
+		{
+			name:    "tampered signature",
+			token:   tamper(signJWT(t, rs256, validClaims(), signRSA)),
+			wantErr: true,
+		},
+		{
+			name:    "tampered claims",
+			token:   replaceClaims(t, signJWT(t, rs256, validClaims(), signRSA), withClaim("sub", "mallory")),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-jwt",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, ErrUnauthenticated)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if principal.Name != "alice" {
+				t.Errorf("principal name = %q, want %q", principal.Name, "alice")
+			}
+			if !principal.AllowsTool("query_traces") || principal.AllowsTool("get_trace_details") {
+				t.Errorf("principal tools = %v, want [list_services query_traces]", principal.Tools)
+			}
+		})
+	}
+}
+
+// tamper flips a bit of the signature of a token.
+func tamper(token string) string {
+	i := strings.LastIndex(token, ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(token[i+1:])
+	signature[0] ^= 1
+	return token[:i+1] + b64(signature)
+}
+
+// replaceClaims swaps the claims of a token, keeping its header and signature.
+func replaceClaims(t *testing.T, token string, claims map[string]any) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parts[0] + "." + b64(claimsJSON) + "." + parts[2]
+}
+
+func TestNewJWTAuthenticatorKeys(t *testing.T) {
+	shortKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// identity providers publish the certificate chain and the key operations as arrays
+	providerKey := rsaJWK("provider", &rsaKey.PublicKey)
+	providerKey["x5c"] = []string{"MIIC+DCCAeCgAwIBAgIJAKq"}
+	providerKey["x5t"] = "NjVBRjY5MDlCMUIwNzU4RTA2QzZFMDQ4QzQ2MDAyQjVDNjk1RTM2Qg"
+	providerKey["key_ops"] = []string{"verify"}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptionKey := ecJWK("enc", &ecKey.PublicKey)
+	encryptionKey["use"] = "enc"
+
+	tests := []struct {
+		name    string
+		keys    []map[string]any
+		wantErr bool
+	}{
+		{
+			name: "EC key",
+			keys: []map[string]any{ecJWK("ec", &ecKey.PublicKey)},
+		},
+		{
+			name: "RSA key with x5c and key_ops",
+			keys: []map[string]any{providerKey},
+		},
+		{
+			name:    "RSA key shorter than 2048 bits",
+			keys:    []map[string]any{rsaJWK("short", &shortKey.PublicKey)},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported curve",
+			keys:    []map[string]any{{"kty": "EC", "crv": "P-192", "x": "AQ", "y": "AQ"}},
+			wantErr: true,
+		},
+		{
+			name:    "no signing keys",
+			keys:    []map[string]any{encryptionKey},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTAuthenticator(writeJWKS(t, tt.keys...), "", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewJWTAuthenticator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
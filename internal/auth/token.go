@@ -0,0 +1,83 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// tokenEntry is a static token in the tokens file.
+type tokenEntry struct {
+	Name  string   `mapstructure:"name"`
+	Token string   `mapstructure:"token"`
+	Tools []string `mapstructure:"tools"`
+}
+
+// TokenAuthenticator authenticates static bearer tokens.
+type TokenAuthenticator struct {
+	tokens []tokenEntry
+}
+
+// NewTokenAuthenticator loads static tokens from a YAML, JSON or TOML file like:
+//
+//	tokens:
+//	  - name: alice
+//	    token: s3cr3t
+//	    tools: [query_traces, get_trace_details] # optional, all tools if empty
+func NewTokenAuthenticator(path string) (*TokenAuthenticator, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	var tokens []tokenEntry
+	if err := v.UnmarshalKey("tokens", &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse tokens file: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found in %s", path)
+	}
+	for i := range tokens {
+		if tokens[i].Token == "" {
+			return nil, fmt.Errorf("token #%d in %s is empty", i+1, path)
+		}
+		if tokens[i].Name == "" {
+			tokens[i].Name = fmt.Sprintf("token #%d", i+1)
+		}
+	}
+	return &TokenAuthenticator{tokens: tokens}, nil
+}
+
+// Authenticate implements Authenticator.
+func (a *TokenAuthenticator) Authenticate(token string) (*Principal, error) {
+	var found *tokenEntry
+	// compare all tokens in constant time to not leak which one matched
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(a.tokens[i].Token), []byte(token)) == 1 {
+			found = &a.tokens[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: found.Name, Tools: found.Tools}, nil
+}
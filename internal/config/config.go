@@ -17,10 +17,65 @@
 
 package config
 
+import (
+	"slices"
+	"time"
+)
+
 const (
 	DefaultSWURL = "http://localhost:12800/graphql"
 )
 
+// OAPAuthConfig holds the credentials applied to every outbound OAP request.
+type OAPAuthConfig struct {
+	// Username and Password for HTTP basic authentication
+	Username string
+	Password string
+
+	// Token is sent as a bearer token, it takes precedence over basic authentication
+	Token string
+
+	// Headers are custom headers sent with every request, e.g. for a gateway in front of OAP
+	Headers map[string]string
+}
+
+// OAPTLSConfig holds the TLS settings of connections to OAP.
+type OAPTLSConfig struct {
+	// CAFile is a PEM encoded CA bundle used to verify the OAP certificate instead of the system roots
+	CAFile string
+
+	// CertFile and KeyFile are the PEM encoded client certificate and key presented to OAP for mutual TLS
+	CertFile string
+	KeyFile  string
+
+	// InsecureSkipVerify disables verification of the OAP certificate
+	InsecureSkipVerify bool
+}
+
+// AuthConfig holds the inbound authentication settings of the HTTP transports.
+type AuthConfig struct {
+	// TokensFile lists static bearer tokens and their tool allowlists
+	TokensFile string
+
+	// JWKSFile holds the public keys to verify JWT bearer tokens
+	JWKSFile string
+
+	// JWTIssuer and JWTAudience, if set, must match the iss and aud claims of JWTs
+	JWTIssuer   string
+	JWTAudience string
+}
+
+// ClusterConfig is a named OAP cluster that tools can query with their cluster argument.
+type ClusterConfig struct {
+	Name string
+
+	// URL of the OAP of the cluster
+	URL string
+
+	// Auth holds the credentials of the cluster, unset fields are inherited from the top-level settings
+	Auth OAPAuthConfig
+}
+
 // MCPServerConfig holds the application configuration.
 type MCPServerConfig struct {
 	// SkyWalking OAP URL to target for API requests (e.g. localhost:12800)
@@ -31,6 +86,50 @@ type MCPServerConfig struct {
 
 	// Path to the log file if not stderr
 	LogFilePath string
+
+	// Profile is the name of the profile selected in the configuration file
+	Profile string
+
+	// Timezone for time calculations, the local system timezone if empty
+	Timezone string
+
+	// DefaultDuration is the time range of metric, log, alarm, event and topology queries without a duration
+	DefaultDuration time.Duration
+
+	// DefaultTraceDuration is the time range of trace queries without a duration
+	DefaultTraceDuration time.Duration
+
+	// ColdTraceLookback is the longest time range searched in cold storage for traces missing from hot storage,
+	// zero disables the search
+	ColdTraceLookback time.Duration
+
+	// ToolGroups are the enabled groups of tools, all groups if empty
+	ToolGroups []string
+
+	// Auth and TLS are the settings of connections to OAP
+	Auth OAPAuthConfig
+	TLS  OAPTLSConfig
+
+	// Clusters are the named OAP clusters, sorted by name
+	Clusters []ClusterConfig
+
+	// DefaultCluster is queried by tools called without cluster, the OAP of URL if empty
+	DefaultCluster string
+}
+
+// Cluster returns the cluster with the given name, or nil if there is none.
+func (c *MCPServerConfig) Cluster(name string) *ClusterConfig {
+	for i := range c.Clusters {
+		if c.Clusters[i].Name == name {
+			return &c.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// ToolGroupEnabled reports whether the tools of a group are registered.
+func (c *MCPServerConfig) ToolGroupEnabled(name string) bool {
+	return len(c.ToolGroups) == 0 || slices.Contains(c.ToolGroups, name)
 }
 
 // StdioServerConfig holds the configuration for Stdio.
@@ -67,6 +166,13 @@ type SSEServerConfig struct {
 
 	// Base path for the sse server
 	BasePath string
+
+	// Inbound authentication, disabled if empty
+	Auth AuthConfig
+
+	// Certificate and key files to serve HTTPS, plain HTTP if empty
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
 type StreamableServerConfig struct {
@@ -88,4 +194,11 @@ type StreamableServerConfig struct {
 
 	// Base path for the Streamable HTTP transport server
 	EndpointPath string
+
+	// Inbound authentication, disabled if empty
+	Auth AuthConfig
+
+	// Certificate and key files to serve HTTPS, plain HTTP if empty
+	TLSCertFile string
+	TLSKeyFile  string
 }
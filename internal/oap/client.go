@@ -0,0 +1,224 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package oap provides the GraphQL client used to talk to SkyWalking OAP.
+package oap
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default client settings
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultMaxRetries   = 2
+	DefaultRetryBackoff = 200 * time.Millisecond
+
+	maxRetryBackoff     = 5 * time.Second
+	maxIdleConnsPerHost = 16
+)
+
+// Config holds the settings of the OAP GraphQL client.
+type Config struct {
+	// Timeout bounds a single HTTP attempt
+	Timeout time.Duration
+
+	// MaxRetries is the number of retries after the first attempt for retryable failures
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, doubled on every further retry
+	RetryBackoff time.Duration
+
+	// TLS is the TLS configuration of connections to OAP, nil for the system defaults
+	TLS *tls.Config
+}
+
+// Request represents a GraphQL request
+type Request struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response represents a GraphQL response
+type Response struct {
+	Data   interface{}         `json:"data"`
+	Errors []GraphQLErrorEntry `json:"errors,omitempty"`
+}
+
+// Client executes GraphQL requests against OAP.
+// All requests share one connection pool, the endpoint and credentials are resolved per request from the context.
+type Client struct {
+	cfg            Config
+	httpClient     *http.Client
+	insecureClient *http.Client
+}
+
+var defaultClient atomic.Pointer[Client]
+
+func init() {
+	defaultClient.Store(NewClient(Config{}))
+}
+
+// Default returns the client shared by all tools.
+func Default() *Client {
+	return defaultClient.Load()
+}
+
+// SetDefault replaces the client shared by all tools.
+func SetDefault(c *Client) {
+	defaultClient.Store(c)
+}
+
+// NewClient creates a client, zero values in cfg are replaced by the defaults.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+
+	transport := NewHTTPTransport(cfg.TLS)
+
+	// the insecure transport keeps the client certificate of the configured TLS settings
+	insecureTLS := &tls.Config{}
+	if cfg.TLS != nil {
+		insecureTLS = cfg.TLS.Clone()
+	}
+	insecureTLS.InsecureSkipVerify = true // #nosec G402
+	insecureTransport := NewHTTPTransport(insecureTLS)
+
+	// an OAP chosen by the MCP client gets the TLS settings without the client certificate
+	clientTransport := NewHTTPTransport(withoutCertificates(cfg.TLS))
+	insecureClientTransport := NewHTTPTransport(withoutCertificates(insecureTLS))
+
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout, Transport: NewTransport(NewScopedTransport(transport, clientTransport))},
+		insecureClient: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: NewTransport(NewScopedTransport(insecureTransport, insecureClientTransport)),
+		},
+	}
+}
+
+// NewHTTPTransport creates a pooled transport for OAP connections with the given TLS configuration.
+func NewHTTPTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	}
+	return transport
+}
+
+// withoutCertificates returns a copy of the TLS configuration without client certificates.
+func withoutCertificates(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.Certificates = nil
+	tlsConfig.GetClientCertificate = nil
+	return tlsConfig
+}
+
+// Execute executes a GraphQL query against the OAP of the current request.
+// Queries are retried with exponential backoff on 5xx responses and reset connections,
+// mutations are never retried since they may already have been applied.
+func (c *Client) Execute(ctx context.Context, query string, variables map[string]interface{}) (*Response, error) {
+	body, err := json.Marshal(Request{Query: query, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
+	}
+
+	maxRetries := c.cfg.MaxRetries
+	if isMutation(query) {
+		maxRetries = 0
+	}
+
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(ctx, body)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= maxRetries || !IsRetryable(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// do sends a single attempt of the request.
+func (c *Client) do(ctx context.Context, body []byte) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, EndpointFromContext(ctx), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.httpClient
+	if InsecureFromContext(ctx) {
+		httpClient = c.insecureClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &TransportError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: truncate(string(bodyBytes))}
+	}
+
+	var graphqlResp Response
+	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
+		return nil, &TransportError{Err: fmt.Errorf("failed to decode GraphQL response: %w", err)}
+	}
+
+	if len(graphqlResp.Errors) > 0 {
+		return nil, &GraphQLError{Errors: graphqlResp.Errors}
+	}
+
+	return &graphqlResp, nil
+}
+
+// isMutation reports whether the GraphQL document is a mutation.
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
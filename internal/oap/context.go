@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package oap
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/apache/skywalking-cli/pkg/contextkey"
+
+	"github.com/apache/skywalking-mcp/internal/config"
+)
+
+// FinalizeURL ensures the URL ends with "/graphql".
+func FinalizeURL(urlStr string) string {
+	if !strings.HasSuffix(urlStr, "/graphql") {
+		urlStr = strings.TrimRight(urlStr, "/") + "/graphql"
+	}
+	return urlStr
+}
+
+// contextString extracts a string value from the context, returning "" if absent.
+func contextString(ctx context.Context, key any) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+// EndpointFromContext resolves the OAP GraphQL endpoint for the current request.
+// The URL is put into the context by the transport, e.g. from the SW-URL header.
+func EndpointFromContext(ctx context.Context) string {
+	urlStr := contextString(ctx, contextkey.BaseURL{})
+	if urlStr == "" {
+		urlStr = config.DefaultSWURL
+	}
+	return FinalizeURL(urlStr)
+}
+
+// InsecureFromContext reports whether TLS verification is disabled for the current request.
+func InsecureFromContext(ctx context.Context) bool {
+	insecure, _ := ctx.Value(contextkey.Insecure{}).(bool)
+	return insecure
+}
+
+// AuthorizationFromContext resolves the Authorization header for the current request,
+// following the same rules as skywalking-cli.
+func AuthorizationFromContext(ctx context.Context) string {
+	authorization := contextString(ctx, contextkey.Authorization{})
+	username := contextString(ctx, contextkey.Username{})
+	password := contextString(ctx, contextkey.Password{})
+	if authorization == "" && username != "" && password != "" {
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	}
+	return authorization
+}
+
+// configuredKey is the context key marking an OAP configured on the server.
+type configuredKey struct{}
+
+// WithConfigured marks the OAP of the context as configured on the server, rather than chosen by the MCP client.
+// Only a configured OAP is sent the client certificate of the TLS settings.
+func WithConfigured(ctx context.Context) context.Context {
+	return context.WithValue(ctx, configuredKey{}, true)
+}
+
+// ConfiguredFromContext reports whether the OAP of the context is configured on the server, see WithConfigured.
+func ConfiguredFromContext(ctx context.Context) bool {
+	configured, _ := ctx.Value(configuredKey{}).(bool)
+	return configured
+}
+
+// headersKey is the context key of the custom headers sent to OAP.
+type headersKey struct{}
+
+// WithHeaders adds custom headers that are sent with every OAP request made with the context,
+// e.g. the headers required by a gateway in front of OAP.
+func WithHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// HeadersFromContext returns the custom headers of the current request.
+func HeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(headersKey{}).(http.Header)
+	return headers
+}
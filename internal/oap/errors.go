@@ -0,0 +1,188 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package oap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind classifies a failure so callers can decide how to react to it.
+type ErrorKind string
+
+const (
+	// KindBadRequest means OAP rejected the query or its arguments.
+	KindBadRequest ErrorKind = "bad_request"
+	// KindUnauthorized means OAP, or a gateway in front of it, rejected the credentials.
+	KindUnauthorized ErrorKind = "unauthorized"
+	// KindUnavailable means OAP could not be reached or failed to serve the request.
+	KindUnavailable ErrorKind = "unavailable"
+	// KindQueryFailed means OAP accepted the query but failed to execute it.
+	KindQueryFailed ErrorKind = "query_failed"
+)
+
+// maxErrorBodyLength limits how much of an unexpected response body is kept in errors.
+const maxErrorBodyLength = 512
+
+// HTTPError is returned when OAP responds with a non-200 HTTP status.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP request failed with status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// Kind classifies the HTTP status.
+func (e *HTTPError) Kind() ErrorKind {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return KindUnauthorized
+	case e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError:
+		return KindUnavailable
+	case e.StatusCode == http.StatusNotFound:
+		// usually a wrong OAP URL or a gateway route that does not exist
+		return KindUnavailable
+	default:
+		return KindBadRequest
+	}
+}
+
+// Retryable reports whether the request may succeed when sent again.
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+// GraphQLErrorLocation is the position in the query an error refers to.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLErrorEntry is a single entry of the "errors" list of a GraphQL response.
+type GraphQLErrorEntry struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *GraphQLErrorEntry) String() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// GraphQLError is returned when OAP responds with GraphQL errors.
+type GraphQLError struct {
+	Errors []GraphQLErrorEntry
+}
+
+func (e *GraphQLError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].String()
+	}
+	return fmt.Sprintf("GraphQL errors: %s", strings.Join(msgs, ", "))
+}
+
+// Kind classifies the GraphQL errors using the classification extension
+// reported by graphql-java, which OAP is built on.
+func (e *GraphQLError) Kind() ErrorKind {
+	for i := range e.Errors {
+		classification, _ := e.Errors[i].Extensions["classification"].(string)
+		switch classification {
+		case "ValidationError", "InvalidSyntax", "OperationNotSupported":
+			return KindBadRequest
+		}
+	}
+	return KindQueryFailed
+}
+
+// Retryable reports whether the request may succeed when sent again.
+func (e *GraphQLError) Retryable() bool {
+	return false
+}
+
+// TransportError is returned when the request could not be sent or the response could not be read.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("failed to execute HTTP request: %v", e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Kind classifies the transport failure.
+func (e *TransportError) Kind() ErrorKind {
+	return KindUnavailable
+}
+
+// Retryable reports whether the connection was reset by OAP or a proxy in front of it.
+func (e *TransportError) Retryable() bool {
+	return errors.Is(e.Err, syscall.ECONNRESET) ||
+		errors.Is(e.Err, io.EOF) ||
+		errors.Is(e.Err, io.ErrUnexpectedEOF)
+}
+
+// classified is implemented by all typed errors of this package.
+type classified interface {
+	error
+	Kind() ErrorKind
+	Retryable() bool
+}
+
+// KindOf returns the kind of the error, or KindQueryFailed for unclassified errors.
+func KindOf(err error) ErrorKind {
+	var c classified
+	if errors.As(err, &c) {
+		return c.Kind()
+	}
+	return KindQueryFailed
+}
+
+// IsRetryable reports whether the failed request may succeed when sent again.
+func IsRetryable(err error) bool {
+	var c classified
+	if errors.As(err, &c) {
+		return c.Retryable()
+	}
+	return false
+}
+
+// truncate shortens response bodies kept in errors.
+func truncate(s string) string {
+	if len(s) <= maxErrorBodyLength {
+		return s
+	}
+	return s[:maxErrorBodyLength] + "..."
+}
@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package oap
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// transport applies the credentials and custom headers of the request context to every outbound request.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base so that every request carries the credentials and custom headers
+// found in its context, see AuthorizationFromContext and HeadersFromContext.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	headers := HeadersFromContext(ctx)
+	authorization := AuthorizationFromContext(ctx)
+	if len(headers) == 0 && (authorization == "" || req.Header.Get("Authorization") != "") {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the original request
+	req = req.Clone(ctx)
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	if authorization != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// scopedTransport sends the requests to the OAP of their context and all other requests through different transports.
+type scopedTransport struct {
+	oap   http.RoundTripper
+	other http.RoundTripper
+}
+
+// NewScopedTransport routes the requests sent to the configured OAP of their context, see EndpointFromContext
+// and WithConfigured, through oap, and all other requests through other, so that the credentials, custom headers
+// and TLS settings of OAP are never sent to other hosts by clients shared with other code, such as http.DefaultClient,
+// nor to an OAP chosen by the MCP client.
+func NewScopedTransport(oap, other http.RoundTripper) http.RoundTripper {
+	return &scopedTransport{oap: oap, other: other}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *scopedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isOAPRequest(req) {
+		return t.oap.RoundTrip(req)
+	}
+	return t.other.RoundTrip(req)
+}
+
+// isOAPRequest reports whether the request is sent to the OAP of its context, and that OAP is configured on the server.
+func isOAPRequest(req *http.Request) bool {
+	if !ConfiguredFromContext(req.Context()) {
+		return false
+	}
+	endpoint, err := url.Parse(EndpointFromContext(req.Context()))
+	if err != nil || req.URL == nil {
+		return false
+	}
+	return strings.EqualFold(endpoint.Scheme, req.URL.Scheme) && strings.EqualFold(endpoint.Host, req.URL.Host)
+}
@@ -123,7 +123,7 @@ func addTraceAnalysisPrompts(s *server.MCPServer) {
 		Arguments: []mcp.PromptArgument{
 			{Name: "trace_id", Description: "The trace ID to analyze", Required: true},
 			{Name: "view", Description: "Analysis view (full, summary, errors_only)", Required: false},
-			{Name: "check_cold_storage", Description: "Check cold storage if not found (true/false)", Required: false},
+			{Name: "check_cold_storage", Description: "Search cold storage beyond the automatic lookback if not found (true/false)", Required: false},
 		},
 	}, traceDeepDiveHandler)
 
@@ -176,7 +176,7 @@ var analysisChains = map[string][]struct {
 	"trace_investigation": {
 		{Tool: "query_traces", Purpose: "Search for traces with specific filters"},
 		{Tool: "get_trace_details", Purpose: "Analyze individual traces in detail"},
-		{Tool: "get_cold_trace_details", Purpose: "Check historical traces if not found in hot storage"},
+		{Tool: "get_cold_trace_details", Purpose: "Search cold storage over a time range of your choice"},
 	},
 	"log_analysis": {
 		{Tool: "query_logs", Purpose: "Search and analyze log entries with filters"},
@@ -417,7 +417,8 @@ func traceInvestigationHandler(_ context.Context, request mcp.GetPromptRequest)
 
 **Historical Investigation**
 - If recent data shows no issues, use cold storage tools
-- Use get_cold_trace_details for older trace data
+- get_trace_details searches cold storage by itself for traces missing from hot storage
+- Use get_cold_trace_details for traces older than its lookback
 
 Provide specific findings and actionable recommendations.`, serviceID, traceState, duration, toolInstructions)
 
@@ -575,10 +576,11 @@ func traceDeepDiveHandler(_ context.Context, request mcp.GetPromptRequest) (*mcp
 - Use full view for complete span analysis
 - Use errors_only view if trace has errors
 
-**Cold Storage Check:**
-- If trace not found in hot storage and check_cold_storage is "%s"
-- Use get_cold_trace_details with same trace_id
-- Check historical data for older traces
+**Storage Stage:**
+- get_trace_details searches cold storage by itself when the trace is not in hot storage,
+  over the last 1d, 7d and 30d up to the configured lookback, and reports the storage stage that served it
+- If the trace is not found within the lookback and check_cold_storage is "%s"
+- Use get_cold_trace_details with same trace_id and a longer duration
 
 **Analysis Depth:**
 
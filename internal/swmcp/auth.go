@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package swmcp
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/apache/skywalking-mcp/internal/auth"
+	"github.com/apache/skywalking-mcp/internal/config"
+)
+
+// authFlags are the inbound authentication flags shared by the HTTP transports.
+var authFlags = []string{"auth-tokens-file", "auth-jwks-file", "auth-jwt-issuer", "auth-jwt-audience"}
+
+// addAuthFlags adds the inbound authentication flags to an HTTP transport command.
+func addAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().String("auth-tokens-file", "",
+		"YAML, JSON or TOML file with static bearer tokens and optional per-token tool allowlists")
+	cmd.Flags().String("auth-jwks-file", "",
+		"JWKS file with the public keys to verify JWT bearer tokens")
+	cmd.Flags().String("auth-jwt-issuer", "",
+		"Required iss claim of JWT bearer tokens")
+	cmd.Flags().String("auth-jwt-audience", "",
+		"Required aud claim of JWT bearer tokens")
+}
+
+// authConfigFromFlags reads the inbound authentication configuration of the running command.
+// The flags are bound when the command runs since both HTTP transports define them.
+func authConfigFromFlags(cmd *cobra.Command) config.AuthConfig {
+	for _, name := range authFlags {
+		_ = viper.BindPFlag(name, cmd.Flags().Lookup(name))
+	}
+	return config.AuthConfig{
+		TokensFile:  viper.GetString("auth-tokens-file"),
+		JWKSFile:    viper.GetString("auth-jwks-file"),
+		JWTIssuer:   viper.GetString("auth-jwt-issuer"),
+		JWTAudience: viper.GetString("auth-jwt-audience"),
+	}
+}
+
+// newAuthenticator creates the authenticator of the HTTP transports, nil if authentication is disabled.
+func newAuthenticator(cfg config.AuthConfig) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.TokensFile != "" {
+		tokens, err := auth.NewTokenAuthenticator(cfg.TokensFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, tokens)
+	}
+	if cfg.JWKSFile != "" {
+		jwt, err := auth.NewJWTAuthenticator(cfg.JWKSFile, cfg.JWTIssuer, cfg.JWTAudience)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, jwt)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// withInboundAuth wraps the handler of an HTTP transport with the authentication middleware.
+// The principal is then available to the context funcs and the tool allowlist is enforced by newMcpServer.
+func withInboundAuth(handler http.Handler, cfg config.AuthConfig) (http.Handler, error) {
+	authenticator, err := newAuthenticator(cfg)
+	if err != nil || authenticator == nil {
+		return handler, err
+	}
+	return auth.Middleware(authenticator, handler), nil
+}
@@ -0,0 +1,249 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package swmcp
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/apache/skywalking-mcp/internal/config"
+	"github.com/apache/skywalking-mcp/internal/tools"
+)
+
+// serverConfig is the configuration applied at startup, see Configure.
+var serverConfig = &config.MCPServerConfig{}
+
+// LoadConfig reads the configuration file given by --config and merges the profile selected by --profile,
+// or by the "profile" key of the file. Flags and environment variables take precedence over the file.
+//
+// A configuration file looks like:
+//
+//	profile: staging
+//	timezone: UTC
+//	profiles:
+//	  prod:
+//	    url: http://oap-prod:12800
+//	    token: change-me
+//	    read-only: true
+//	    default-duration: 1h
+//	    tool-groups: [trace, metrics, topology]
+//	  staging:
+//	    url: http://oap-staging:12800
+//
+// Named OAP clusters, selected with the cluster argument of the tools, are configured like:
+//
+//	default-cluster: eu
+//	clusters:
+//	  eu:
+//	    url: http://oap-eu:12800
+//	  us:
+//	    url: http://oap-us:12800
+//	    token: change-me
+func LoadConfig() (*config.MCPServerConfig, error) {
+	if configFile := viper.GetString("config"); configFile != "" {
+		viper.SetConfigFile(configFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	profile := viper.GetString("profile")
+	if profile != "" {
+		if err := mergeProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	auth, err := configuredAuth()
+	if err != nil {
+		return nil, err
+	}
+	toolGroups, err := configuredToolGroups()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := configuredClusters(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config.MCPServerConfig{
+		URL:                  configuredURL(),
+		ReadOnly:             viper.GetBool("read-only"),
+		LogFilePath:          viper.GetString("log-file"),
+		Profile:              profile,
+		Timezone:             viper.GetString("timezone"),
+		DefaultDuration:      viper.GetDuration("default-duration"),
+		DefaultTraceDuration: viper.GetDuration("default-trace-duration"),
+		ColdTraceLookback:    viper.GetDuration("cold-trace-lookback"),
+		ToolGroups:           toolGroups,
+		Auth:                 auth,
+		TLS: config.OAPTLSConfig{
+			CAFile:             viper.GetString("ca-file"),
+			CertFile:           viper.GetString("cert-file"),
+			KeyFile:            viper.GetString("key-file"),
+			InsecureSkipVerify: viper.GetBool("insecure-skip-verify"),
+		},
+		Clusters:       clusters,
+		DefaultCluster: viper.GetString("default-cluster"),
+	}
+	if cfg.DefaultCluster != "" && cfg.Cluster(cfg.DefaultCluster) == nil {
+		return nil, fmt.Errorf("default cluster %q is not configured", cfg.DefaultCluster)
+	}
+	return cfg, nil
+}
+
+// mergeProfile merges the settings of a profile over the top-level settings of the configuration file.
+func mergeProfile(profile string) error {
+	if viper.ConfigFileUsed() == "" {
+		return fmt.Errorf("profile %q requires a config file, see --config", profile)
+	}
+	profiles := viper.GetStringMap("profiles")
+	settings, ok := profiles[profile].(map[string]any)
+	if !ok {
+		return fmt.Errorf("profile %q not found in %s, available profiles: %s",
+			profile, viper.ConfigFileUsed(), strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	return viper.MergeConfigMap(settings)
+}
+
+// Configure applies the server configuration to the OAP client and the tools.
+func Configure(cfg *config.MCPServerConfig) error {
+	serverConfig = cfg
+	tools.SetDefaultDurations(cfg.DefaultDuration, cfg.DefaultTraceDuration)
+	tools.SetColdTraceLookback(cfg.ColdTraceLookback)
+	if len(cfg.Clusters) > 0 {
+		names := make([]string, len(cfg.Clusters))
+		for i := range cfg.Clusters {
+			names[i] = cfg.Clusters[i].Name
+		}
+		tools.SetClusters(names, withCluster)
+	}
+	return configureOAPClient(cfg)
+}
+
+// configuredClusters reads the named OAP clusters, their credentials default to the top-level ones.
+func configuredClusters(defaultAuth config.OAPAuthConfig) ([]config.ClusterConfig, error) {
+	names := slices.Sorted(maps.Keys(viper.GetStringMap("clusters")))
+	clusters := make([]config.ClusterConfig, 0, len(names))
+	for _, name := range names {
+		if name == tools.AllClusters {
+			return nil, fmt.Errorf("cluster name %q is reserved to query all clusters", name)
+		}
+		settings := viper.Sub("clusters." + name)
+		if settings == nil || settings.GetString("url") == "" {
+			return nil, fmt.Errorf("cluster %q has no url", name)
+		}
+
+		auth, err := authFrom(settings)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: %w", name, err)
+		}
+		if auth.Username == "" && auth.Token == "" {
+			auth.Username, auth.Password, auth.Token = defaultAuth.Username, defaultAuth.Password, defaultAuth.Token
+		}
+		headers := maps.Clone(defaultAuth.Headers)
+		if headers == nil {
+			headers = make(map[string]string, len(auth.Headers))
+		}
+		maps.Copy(headers, auth.Headers)
+		auth.Headers = headers
+
+		clusters = append(clusters, config.ClusterConfig{Name: name, URL: settings.GetString("url"), Auth: auth})
+	}
+	return clusters, nil
+}
+
+// configuredToolGroups reads the enabled tool groups, given as a list or comma separated.
+func configuredToolGroups() ([]string, error) {
+	var groups []string
+	for _, value := range viper.GetStringSlice("tool-groups") {
+		for _, group := range strings.Split(value, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	names := make([]string, len(toolGroups))
+	for i := range toolGroups {
+		names[i] = toolGroups[i].name
+	}
+	for _, group := range groups {
+		if !slices.Contains(names, group) {
+			return nil, fmt.Errorf("unknown tool group %q, available groups: %s", group, strings.Join(names, ", "))
+		}
+	}
+	return groups, nil
+}
+
+// configuredAuth reads the OAP credentials from the --sw-* flags, their SW_* environment variables or the configuration file.
+func configuredAuth() (config.OAPAuthConfig, error) {
+	return authFrom(viper.GetViper())
+}
+
+// authFrom reads the OAP credentials from the settings, the top-level ones or those of a cluster.
+func authFrom(v *viper.Viper) (config.OAPAuthConfig, error) {
+	auth := config.OAPAuthConfig{
+		Username: v.GetString("username"),
+		Password: v.GetString("password"),
+		Token:    v.GetString("token"),
+	}
+	if (auth.Username == "") != (auth.Password == "") {
+		return auth, errors.New("--sw-username and --sw-password must be specified together")
+	}
+
+	var rawHeaders []string
+	switch value := v.Get("header").(type) {
+	case string:
+		// SW_HEADER environment variable, one header per line since header values may contain commas
+		rawHeaders = strings.Split(value, "\n")
+	default:
+		// flags or a list in the configuration file
+		rawHeaders = v.GetStringSlice("header")
+	}
+	headers, err := parseHeaders(rawHeaders)
+	if err != nil {
+		return auth, err
+	}
+	auth.Headers = headers
+	return auth, nil
+}
+
+// parseHeaders parses headers in the form "Name: value" or "Name=value".
+func parseHeaders(rawHeaders []string) (map[string]string, error) {
+	headers := make(map[string]string, len(rawHeaders))
+	for _, raw := range rawHeaders {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		idx := strings.IndexAny(raw, ":=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\" or \"Name=value\"", raw)
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(raw[:idx]))] = strings.TrimSpace(raw[idx+1:])
+	}
+	return headers, nil
+}
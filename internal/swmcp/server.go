@@ -22,36 +22,64 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/apache/skywalking-cli/pkg/contextkey"
 
+	"github.com/apache/skywalking-mcp/internal/auth"
 	"github.com/apache/skywalking-mcp/internal/config"
+	"github.com/apache/skywalking-mcp/internal/oap"
 	"github.com/apache/skywalking-mcp/internal/prompts"
 	"github.com/apache/skywalking-mcp/internal/resources"
 	"github.com/apache/skywalking-mcp/internal/tools"
 )
 
+// toolGroups are the groups of tools that can be enabled in the configuration.
+var toolGroups = []struct {
+	name string
+	add  func(*server.MCPServer)
+}{
+	{"metadata", tools.AddMetadataTools},
+	{"trace", tools.AddTraceTools},
+	{"metrics", tools.AddMetricsTools},
+	{"log", tools.AddLogTools},
+	{"mqe", tools.AddMQETools},
+	{"alarm", tools.AddAlarmTools},
+	{"topology", tools.AddTopologyTools},
+	{"event", tools.AddEventTools},
+	{"profiling", tools.AddProfilingTools},
+}
+
+// readHeaderTimeout bounds the time to read the request headers of the HTTP transports.
+const readHeaderTimeout = 10 * time.Second
+
 // newMcpServer creates a new MCP server instance,
 // and we can add various tools and capabilities to it.
-func newMcpServer() *server.MCPServer {
+// When readOnly is true, tools that mutate OAP state are not registered.
+// Only the tool groups enabled in the server configuration are registered.
+func newMcpServer(readOnly bool) *server.MCPServer {
 	mcpServer := server.NewMCPServer(
 		"skywalking-mcp",
 		"0.1.0",
 		server.WithResourceCapabilities(true, true),
 		server.WithPromptCapabilities(true),
-		server.WithLogging())
+		server.WithLogging(),
+		// enforce the tool allowlist of authenticated HTTP clients
+		server.WithToolFilter(auth.ToolFilter),
+		server.WithToolHandlerMiddleware(auth.ToolMiddleware))
 
 	// add tools and capabilities to the MCP server
-	tools.AddTraceTools(mcpServer)
-	tools.AddMetricsTools(mcpServer)
-	tools.AddLogTools(mcpServer)
-	tools.AddMQETools(mcpServer)
-	tools.AddAlarmTools(mcpServer)
-	tools.AddTopologyTools(mcpServer)
-	tools.AddEventTools(mcpServer)
+	tools.SetReadOnly(readOnly)
+	for _, group := range toolGroups {
+		if serverConfig.ToolGroupEnabled(group.name) {
+			group.add(mcpServer)
+		}
+	}
 
 	// add MQE documentation resources
 	resources.AddMQEResources(mcpServer)
@@ -62,6 +90,42 @@ func newMcpServer() *server.MCPServer {
 	return mcpServer
 }
 
+// oapAuth and oapTLS hold the credentials and TLS settings for OAP configured at startup, see Configure.
+var (
+	oapAuth config.OAPAuthConfig
+	oapTLS  config.OAPTLSConfig
+)
+
+// configureOAPClient creates the client shared by all tools to query OAP from the server configuration.
+func configureOAPClient(cfg *config.MCPServerConfig) error {
+	oapAuth = cfg.Auth
+	oapTLS = cfg.TLS
+	tlsConfig, err := oap.NewTLSConfig(oapTLS)
+	if err != nil {
+		return err
+	}
+
+	oap.SetDefault(oap.NewClient(oap.Config{
+		Timeout:      viper.GetDuration("timeout"),
+		MaxRetries:   viper.GetInt("retries"),
+		RetryBackoff: viper.GetDuration("retry-backoff"),
+		TLS:          tlsConfig,
+	}))
+
+	// skywalking-cli sends its requests through http.DefaultClient, configure it so those requests use
+	// the TLS settings and carry the custom headers as well. Requests to other hosts are left untouched.
+	http.DefaultClient.Transport = oap.NewScopedTransport(oap.NewTransport(oap.NewHTTPTransport(tlsConfig)), http.DefaultTransport)
+	return nil
+}
+
+// cliInsecure reports whether the insecure flag is set in the skywalking-cli context.
+// skywalking-cli builds its own client for insecure requests, which neither presents the client
+// certificate nor sends the custom headers. The transport of http.DefaultClient already skips
+// verification, so the flag is only passed on when nothing would be lost.
+func cliInsecure(auth config.OAPAuthConfig) bool {
+	return oapTLS.InsecureSkipVerify && oapTLS.CertFile == "" && len(auth.Headers) == 0
+}
+
 func initLogger(logFilePath string) (*logrus.Logger, error) {
 	if logFilePath == "" {
 		return logrus.New(), nil
@@ -80,6 +144,12 @@ func initLogger(logFilePath string) (*logrus.Logger, error) {
 	return logrusLogger, nil
 }
 
+// Headers of the HTTP transports that override the server configuration per request
+const (
+	skywalkingURLHeader           = "SW-URL"
+	skywalkingAuthorizationHeader = "SW-Authorization"
+)
+
 // WithSkyWalkingURLAndInsecure adds SkyWalking URL and insecure flag to the context
 // This ensures all downstream requests will have contextkey.BaseURL{} and contextkey.Insecure{}
 func WithSkyWalkingURLAndInsecure(ctx context.Context, url string, insecure bool) context.Context {
@@ -88,45 +158,109 @@ func WithSkyWalkingURLAndInsecure(ctx context.Context, url string, insecure bool
 	return ctx
 }
 
-const (
-	skywalkingURLEnvVar = "SW_URL"
-)
+// WithSkyWalkingAuth adds the OAP credentials and custom headers to the context.
+// A non-empty authorization, e.g. passed through from the MCP client, overrides the configured credentials.
+func WithSkyWalkingAuth(ctx context.Context, auth config.OAPAuthConfig, authorization string) context.Context {
+	if authorization == "" && auth.Token != "" {
+		authorization = "Bearer " + auth.Token
+	}
+	ctx = context.WithValue(ctx, contextkey.Username{}, auth.Username)
+	ctx = context.WithValue(ctx, contextkey.Password{}, auth.Password)
+	ctx = context.WithValue(ctx, contextkey.Authorization{}, authorization)
 
-// urlAndInsecureFromEnv extracts URL and insecure flag purely from environment variables.
-func urlAndInsecureFromEnv() (string, bool) {
-	urlStr := os.Getenv(skywalkingURLEnvVar)
+	if len(auth.Headers) > 0 {
+		headers := make(http.Header, len(auth.Headers))
+		for name, value := range auth.Headers {
+			headers.Set(name, value)
+		}
+		ctx = oap.WithHeaders(ctx, headers)
+	}
+	return ctx
+}
+
+// withCluster points the context to the named OAP cluster, or to the default cluster for an empty name.
+// Without a default cluster, an empty name keeps the OAP resolved from the request or the configuration.
+func withCluster(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		name = serverConfig.DefaultCluster
+		if name == "" {
+			return ctx, nil
+		}
+	}
+	cluster := serverConfig.Cluster(name)
+	if cluster == nil {
+		names := make([]string, len(serverConfig.Clusters))
+		for i := range serverConfig.Clusters {
+			names[i] = serverConfig.Clusters[i].Name
+		}
+		return ctx, fmt.Errorf("unknown cluster %q, available clusters: %s", name, strings.Join(names, ", "))
+	}
+
+	ctx = WithSkyWalkingURLAndInsecure(ctx, oap.FinalizeURL(cluster.URL), cliInsecure(cluster.Auth))
+	return WithSkyWalkingAuth(oap.WithConfigured(ctx), cluster.Auth, ""), nil
+}
+
+// authForURL returns the credentials configured for the OAP of the URL, that of --sw-url or of a cluster.
+// It returns false for any other OAP, which must not be sent the configured credentials.
+func authForURL(urlStr string) (config.OAPAuthConfig, bool) {
+	if urlStr == oap.FinalizeURL(configuredURL()) {
+		return oapAuth, true
+	}
+	for i := range serverConfig.Clusters {
+		if urlStr == oap.FinalizeURL(serverConfig.Clusters[i].URL) {
+			return serverConfig.Clusters[i].Auth, true
+		}
+	}
+	return config.OAPAuthConfig{}, false
+}
+
+// configuredURL returns the OAP URL configured by the --sw-url flag or the SW_URL environment variable.
+func configuredURL() string {
+	urlStr := viper.GetString("url")
 	if urlStr == "" {
 		urlStr = config.DefaultSWURL
 	}
-	return tools.FinalizeURL(urlStr), false
+	return urlStr
+}
+
+// urlAndInsecureFromEnv extracts URL and insecure flag from the server configuration.
+func urlAndInsecureFromEnv() (string, bool) {
+	return oap.FinalizeURL(configuredURL()), cliInsecure(oapAuth)
 }
 
 // urlAndInsecureFromHeaders extracts URL and insecure flag for a request.
-// URL is sourced from Header > Environment > Default.
-// Insecure flag is now hardcoded to false.
+// URL is sourced from Header > Configuration (flag or environment) > Default.
+// Insecure flag is always taken from the configuration, clients cannot disable verification.
 func urlAndInsecureFromHeaders(req *http.Request) (string, bool) {
-	urlStr := req.Header.Get("SW-URL")
+	urlStr := req.Header.Get(skywalkingURLHeader)
 	if urlStr == "" {
-		urlStr = os.Getenv(skywalkingURLEnvVar)
-		if urlStr == "" {
-			urlStr = config.DefaultSWURL
-		}
+		urlStr = configuredURL()
 	}
 
-	return tools.FinalizeURL(urlStr), false
+	return oap.FinalizeURL(urlStr), cliInsecure(oapAuth)
 }
 
-// WithSkyWalkingContextFromEnv injects the SkyWalking URL and insecure
-// settings from environment variables into the context.
+// WithSkyWalkingContextFromEnv injects the SkyWalking URL, insecure
+// and credential settings from the server configuration into the context.
 var WithSkyWalkingContextFromEnv server.StdioContextFunc = func(ctx context.Context) context.Context {
-	urlStr, _ := urlAndInsecureFromEnv()
-	return WithSkyWalkingURLAndInsecure(ctx, urlStr, false)
+	urlStr, insecure := urlAndInsecureFromEnv()
+	ctx = WithSkyWalkingURLAndInsecure(ctx, urlStr, insecure)
+	return WithSkyWalkingAuth(oap.WithConfigured(ctx), oapAuth, "")
 }
 
 // withSkyWalkingContextFromRequest is the shared logic for enriching context from an http.Request.
+// The SW-Authorization header is passed through to OAP as the Authorization header.
+// The configured credentials, custom headers and client certificate are only sent to a configured OAP,
+// an OAP chosen with the SW-URL header only gets the credentials of the SW-Authorization header.
 func withSkyWalkingContextFromRequest(ctx context.Context, req *http.Request) context.Context {
-	urlStr, _ := urlAndInsecureFromHeaders(req)
-	return WithSkyWalkingURLAndInsecure(ctx, urlStr, false)
+	urlStr, insecure := urlAndInsecureFromHeaders(req)
+	ctx = WithSkyWalkingURLAndInsecure(ctx, urlStr, insecure)
+	authorization := req.Header.Get(skywalkingAuthorizationHeader)
+	auth, ok := authForURL(urlStr)
+	if !ok {
+		return WithSkyWalkingAuth(ctx, config.OAPAuthConfig{}, authorization)
+	}
+	return WithSkyWalkingAuth(oap.WithConfigured(ctx), auth, authorization)
 }
 
 // EnhanceStdioContextFunc returns a StdioContextFunc that enriches the context
@@ -137,12 +271,14 @@ func EnhanceStdioContextFunc() server.StdioContextFunc {
 
 // EnhanceSSEContextFunc returns a SSEContextFunc that enriches the context
 // with SkyWalking settings from SSE request headers.
+// The principal of authenticated requests is kept, so its tool allowlist applies to the request.
 func EnhanceSSEContextFunc() server.SSEContextFunc {
 	return withSkyWalkingContextFromRequest
 }
 
 // EnhanceHTTPContextFunc returns a HTTPContextFunc that enriches the context
 // with SkyWalking settings from HTTP request headers.
+// The principal of authenticated requests is kept, so its tool allowlist applies to the request.
 func EnhanceHTTPContextFunc() server.HTTPContextFunc {
 	return withSkyWalkingContextFromRequest
 }
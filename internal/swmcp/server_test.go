@@ -0,0 +1,132 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package swmcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/apache/skywalking-mcp/internal/config"
+	"github.com/apache/skywalking-mcp/internal/oap"
+)
+
+// newRecordingOAP starts an OAP stub that records the headers of the last request.
+func newRecordingOAP(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+func TestWithSkyWalkingContextFromRequest(t *testing.T) {
+	configured, configuredHeaders := newRecordingOAP(t)
+	cluster, clusterHeaders := newRecordingOAP(t)
+	foreign, foreignHeaders := newRecordingOAP(t)
+
+	viper.Set("url", configured.URL)
+	t.Cleanup(func() { viper.Set("url", "") })
+	oapAuth = config.OAPAuthConfig{Token: "configured-token", Headers: map[string]string{"X-Gateway-Key": "secret"}}
+	serverConfig = &config.MCPServerConfig{Clusters: []config.ClusterConfig{
+		{Name: "cluster", URL: cluster.URL, Auth: config.OAPAuthConfig{Username: "user", Password: "pass"}},
+	}}
+	t.Cleanup(func() {
+		oapAuth = config.OAPAuthConfig{}
+		serverConfig = &config.MCPServerConfig{}
+	})
+
+	tests := []struct {
+		name              string
+		url               string
+		authorization     string
+		received          *http.Header
+		wantAuthorization string
+		wantGatewayKey    string
+		wantConfigured    bool
+	}{
+		{
+			name:              "configured OAP",
+			received:          configuredHeaders,
+			wantAuthorization: "Bearer configured-token",
+			wantGatewayKey:    "secret",
+			wantConfigured:    true,
+		},
+		{
+			name:              "configured OAP with client credentials",
+			url:               configured.URL,
+			authorization:     "Bearer client-token",
+			received:          configuredHeaders,
+			wantAuthorization: "Bearer client-token",
+			wantGatewayKey:    "secret",
+			wantConfigured:    true,
+		},
+		{
+			name:              "configured cluster",
+			url:               cluster.URL + "/graphql",
+			received:          clusterHeaders,
+			wantAuthorization: "Basic dXNlcjpwYXNz",
+			wantConfigured:    true,
+		},
+		{
+			name:     "foreign OAP",
+			url:      foreign.URL,
+			received: foreignHeaders,
+		},
+		{
+			name:              "foreign OAP with client credentials",
+			url:               foreign.URL,
+			authorization:     "Bearer client-token",
+			received:          foreignHeaders,
+			wantAuthorization: "Bearer client-token",
+		},
+	}
+	client := oap.NewClient(oap.Config{MaxRetries: 0})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", http.NoBody)
+			if tt.url != "" {
+				req.Header.Set(skywalkingURLHeader, tt.url)
+			}
+			if tt.authorization != "" {
+				req.Header.Set(skywalkingAuthorizationHeader, tt.authorization)
+			}
+			ctx := withSkyWalkingContextFromRequest(context.Background(), req)
+
+			if got := oap.ConfiguredFromContext(ctx); got != tt.wantConfigured {
+				t.Errorf("ConfiguredFromContext() = %v, want %v", got, tt.wantConfigured)
+			}
+			if _, err := client.Execute(ctx, "query { version }", nil); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got := tt.received.Get("Authorization"); got != tt.wantAuthorization {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuthorization)
+			}
+			if got := tt.received.Get("X-Gateway-Key"); got != tt.wantGatewayKey {
+				t.Errorf("X-Gateway-Key = %q, want %q", got, tt.wantGatewayKey)
+			}
+		})
+	}
+}
@@ -40,11 +40,14 @@ func NewSSEServer() *cobra.Command {
 		Use:   "sse",
 		Short: "Start SSE server",
 		Long:  `Start a server that listens for Server-Sent Events (SSE) on the specified address.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			sseServerConfig := config.SSEServerConfig{
+				ReadOnly: viper.GetBool("read-only"),
 				Address:  viper.GetString("sse-address"),
 				BasePath: viper.GetString("base-path"),
+				Auth:     authConfigFromFlags(cmd),
 			}
+			sseServerConfig.TLSCertFile, sseServerConfig.TLSKeyFile = tlsFilesFromFlags(cmd)
 
 			return runSSEServer(context.Background(), &sseServerConfig)
 		},
@@ -57,6 +60,8 @@ func NewSSEServer() *cobra.Command {
 		"Base path for the sse server")
 	_ = viper.BindPFlag("sse-address", sseCmd.Flags().Lookup("sse-address"))
 	_ = viper.BindPFlag("base-path", sseCmd.Flags().Lookup("base-path"))
+	addAuthFlags(sseCmd)
+	addTLSFlags(sseCmd)
 
 	return sseCmd
 }
@@ -71,17 +76,26 @@ func runSSEServer(ctx context.Context, cfg *config.SSEServerConfig) error {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
+	httpServer := &http.Server{Addr: cfg.Address, ReadHeaderTimeout: readHeaderTimeout}
 	sseServer := server.NewSSEServer(
-		newMcpServer(),
+		newMcpServer(cfg.ReadOnly),
 		server.WithStaticBasePath(cfg.BasePath),
 		server.WithSSEContextFunc(EnhanceSSEContextFunc()),
+		server.WithHTTPServer(httpServer),
 	)
+	if httpServer.Handler, err = withInboundAuth(sseServer, cfg.Auth); err != nil {
+		return err
+	}
+	scheme, err := configureTLS(httpServer, cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return err
+	}
 	ssePath := sseServer.CompleteSsePath()
-	log.Printf("Starting SkyWalking MCP server using SSE transport listening on http://%s%s\n ", cfg.Address, ssePath)
+	log.Printf("Starting SkyWalking MCP server using SSE transport listening on %s://%s%s\n ", scheme, cfg.Address, ssePath)
 
 	errCh := make(chan error, 1)
 	go func() {
-		if err := sseServer.Start(cfg.Address); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := listenAndServe(httpServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err // bubble up real crashes
 		}
 	}()
@@ -66,7 +66,7 @@ func runStdioServer(ctx context.Context, cfg *config.StdioServerConfig) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	stdioServer := server.NewStdioServer(newMcpServer())
+	stdioServer := server.NewStdioServer(newMcpServer(cfg.ReadOnly))
 
 	logger, err := initLogger(cfg.LogFilePath)
 	if err != nil {
@@ -19,6 +19,7 @@ package swmcp
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/mark3labs/mcp-go/server"
 	log "github.com/sirupsen/logrus"
@@ -33,11 +34,14 @@ func NewStreamable() *cobra.Command {
 		Use:   "streamable",
 		Short: "Start Streamable server",
 		Long:  `Starting SkyWalking MCP server with Streamable HTTP transport.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			streamableConfig := config.StreamableServerConfig{
+				ReadOnly:     viper.GetBool("read-only"),
 				Address:      viper.GetString("address"),
 				EndpointPath: viper.GetString("endpoint-path"),
+				Auth:         authConfigFromFlags(cmd),
 			}
+			streamableConfig.TLSCertFile, streamableConfig.TLSKeyFile = tlsFilesFromFlags(cmd)
 
 			return runStreamableServer(&streamableConfig)
 		},
@@ -50,22 +54,36 @@ func NewStreamable() *cobra.Command {
 		"The path for the streamable-http server")
 	_ = viper.BindPFlag("address", streamableCmd.Flags().Lookup("address"))
 	_ = viper.BindPFlag("endpoint-path", streamableCmd.Flags().Lookup("endpoint-path"))
+	addAuthFlags(streamableCmd)
+	addTLSFlags(streamableCmd)
 
 	return streamableCmd
 }
 
 // runStreamableServer starts the Streamable server with the provided configuration.
 func runStreamableServer(cfg *config.StreamableServerConfig) error {
-	httpServer := server.NewStreamableHTTPServer(
-		newMcpServer(),
+	mux := http.NewServeMux()
+	httpServer := &http.Server{Addr: cfg.Address, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+	streamableServer := server.NewStreamableHTTPServer(
+		newMcpServer(cfg.ReadOnly),
 		server.WithStateLess(true),
 		server.WithLogger(log.StandardLogger()),
 		server.WithHTTPContextFunc(EnhanceHTTPContextFunc()),
-		server.WithEndpointPath(viper.GetString("endpoint-path")),
+		server.WithEndpointPath(cfg.EndpointPath),
+		server.WithStreamableHTTPServer(httpServer),
 	)
-	log.Infof("streamable HTTP server listening on %s%s\n", cfg.Address, cfg.EndpointPath)
+	handler, err := withInboundAuth(streamableServer, cfg.Auth)
+	if err != nil {
+		return err
+	}
+	mux.Handle(cfg.EndpointPath, handler)
+	scheme, err := configureTLS(httpServer, cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+	log.Infof("streamable HTTP server listening on %s://%s%s\n", scheme, cfg.Address, cfg.EndpointPath)
 
-	if err := httpServer.Start(cfg.Address); err != nil {
+	if err := listenAndServe(httpServer); err != nil {
 		return fmt.Errorf("streamable HTTP server error: %v", err)
 	}
 
@@ -0,0 +1,166 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package swmcp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// certCheckInterval is the minimum time between two checks of the certificate files for changes.
+const certCheckInterval = 10 * time.Second
+
+// tlsFlags are the TLS flags shared by the HTTP transports.
+var tlsFlags = []string{"tls-cert", "tls-key"}
+
+// addTLSFlags adds the TLS flags to an HTTP transport command.
+func addTLSFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tls-cert", "", "PEM encoded certificate to serve HTTPS, reloaded when the file changes")
+	cmd.Flags().String("tls-key", "", "PEM encoded private key of the certificate to serve HTTPS")
+}
+
+// tlsFilesFromFlags reads the certificate and key files of the running command.
+// The flags are bound when the command runs since both HTTP transports define them.
+func tlsFilesFromFlags(cmd *cobra.Command) (certFile, keyFile string) {
+	for _, name := range tlsFlags {
+		_ = viper.BindPFlag(name, cmd.Flags().Lookup(name))
+	}
+	return viper.GetString("tls-cert"), viper.GetString("tls-key")
+}
+
+// certReloader serves a certificate and reloads it when the certificate or key file changes,
+// e.g. when it is renewed by cert-manager or certbot.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// newCertReloader loads the certificate, so invalid files fail at startup.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// latestModTime returns the latest modification time of the certificate and key files.
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+// The files are checked at most every certCheckInterval, the previous certificate
+// is kept if the new files cannot be loaded, e.g. while they are being replaced.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, modTime, checkedAt := r.cert, r.modTime, r.checkedAt
+	r.mu.RUnlock()
+	if time.Since(checkedAt) < certCheckInterval {
+		return cert, nil
+	}
+
+	r.mu.Lock()
+	r.checkedAt = time.Now()
+	r.mu.Unlock()
+
+	latest, err := r.latestModTime()
+	if err != nil || !latest.After(modTime) {
+		return cert, nil
+	}
+	if err := r.reload(); err != nil {
+		logrus.Warnf("keeping the current TLS certificate: %v", err)
+		return cert, nil
+	}
+	logrus.Infof("reloaded TLS certificate %s", r.certFile)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// configureTLS makes the HTTP server serve HTTPS with the certificate and key files.
+// It returns the URL scheme of the server.
+func configureTLS(httpServer *http.Server, certFile, keyFile string) (string, error) {
+	if certFile == "" && keyFile == "" {
+		return "http", nil
+	}
+	if certFile == "" || keyFile == "" {
+		return "", errors.New("--tls-cert and --tls-key must be specified together")
+	}
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return "", err
+	}
+	httpServer.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	return "https", nil
+}
+
+// listenAndServe serves plain HTTP or, if configureTLS set up TLS, HTTPS.
+func listenAndServe(httpServer *http.Server) error {
+	if httpServer.TLSConfig != nil {
+		// the certificate is served by TLSConfig.GetCertificate
+		return httpServer.ListenAndServeTLS("", "")
+	}
+	return httpServer.ListenAndServe()
+}
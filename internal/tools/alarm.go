@@ -24,7 +24,6 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/spf13/viper"
 	api "skywalking.apache.org/repo/goapi/query"
 )
 
@@ -62,7 +61,7 @@ func queryAlarms(ctx context.Context, req *AlarmQueryRequest) (*mcp.CallToolResu
 	if req.Duration != "" {
 		duration = ParseDuration(req.Duration, false)
 	} else {
-		duration = BuildDuration(req.Start, req.End, "", false, DefaultDuration)
+		duration = BuildDuration(req.Start, req.End, "", false, configuredDurationMinutes())
 	}
 
 	// Build pagination
@@ -122,9 +121,9 @@ func queryAlarms(ctx context.Context, req *AlarmQueryRequest) (*mcp.CallToolResu
 		variables["keyword"] = req.Keyword
 	}
 
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
+	result, err := executeGraphQL(ctx, query, variables)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to query alarms: %v", err)), nil
+		return oapErrorResult("query alarms", err), nil
 	}
 
 	jsonBytes, err := json.Marshal(result.Data)
@@ -134,8 +133,53 @@ func queryAlarms(ctx context.Context, req *AlarmQueryRequest) (*mcp.CallToolResu
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// Limits of the alarms fetched to flag alarming services
+const (
+	serviceAlarmsPageSize = 100
+	serviceAlarmsMaxPages = 5
+)
+
+// serviceAlarmsQuery queries the alarms of services in a time range
+const serviceAlarmsQuery = `
+	query getAlarm($duration: Duration!, $paging: Pagination!) {
+		result: getAlarm(duration: $duration, scope: Service, paging: $paging) {
+			msgs {
+				startTime
+				id
+				name
+				message
+			}
+		}
+	}
+`
+
+// queryServiceAlarms fetches the alarms of services fired in the time range, by service ID.
+// At most serviceAlarmsMaxPages pages are fetched, the most recent alarms come first.
+func queryServiceAlarms(ctx context.Context, duration api.Duration) (map[string][]*api.AlarmMessage, error) {
+	alarms := make(map[string][]*api.AlarmMessage)
+	for pageNum := 1; pageNum <= serviceAlarmsMaxPages; pageNum++ {
+		var data struct {
+			Result api.Alarms `json:"result"`
+		}
+		variables := map[string]interface{}{
+			"duration": duration,
+			"paging":   BuildPagination(pageNum, serviceAlarmsPageSize),
+		}
+		if err := queryGraphQL(ctx, serviceAlarmsQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		for _, msg := range data.Result.Msgs {
+			alarms[msg.ID] = append(alarms[msg.ID], msg)
+		}
+		if len(data.Result.Msgs) < serviceAlarmsPageSize {
+			break
+		}
+	}
+	return alarms, nil
+}
+
 // AlarmQueryTool is a tool for querying alarms
-var AlarmQueryTool = NewTool[AlarmQueryRequest, *mcp.CallToolResult](
+var AlarmQueryTool = NewFanOutTool[AlarmQueryRequest, *mcp.CallToolResult](
 	"query_alarms",
 	`Query alarms from SkyWalking OAP with flexible filters.
 
@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AllClusters is the value of the cluster argument that queries all clusters, for tools supporting fan-out.
+const AllClusters = "all"
+
+// Named OAP clusters, see SetClusters
+var (
+	clusterNames []string
+	withCluster  func(ctx context.Context, name string) (context.Context, error)
+)
+
+// SetClusters configures the named OAP clusters that every tool can query with its cluster argument.
+// resolve returns the context to query the named cluster, or the default OAP for an empty name.
+func SetClusters(names []string, resolve func(ctx context.Context, name string) (context.Context, error)) {
+	clusterNames = names
+	withCluster = resolve
+}
+
+// ClusterResult is the result of a fan-out tool for a single cluster.
+type ClusterResult struct {
+	Cluster string `json:"cluster"`
+	Result  any    `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// FanOutResult is the result of a fan-out tool across all clusters.
+type FanOutResult struct {
+	Results   []ClusterResult `json:"results"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+}
+
+// clusterOption adds the cluster argument to a tool.
+func clusterOption(fanOut bool) mcp.ToolOption {
+	values := clusterNames
+	desc := fmt.Sprintf("Name of the OAP cluster to query: %s. Defaults to the default cluster.", strings.Join(clusterNames, ", "))
+	if fanOut {
+		values = append(append([]string{}, clusterNames...), AllClusters)
+		desc += fmt.Sprintf(" Use %q to query all clusters concurrently, results are tagged with their cluster.", AllClusters)
+	}
+	return mcp.WithString("cluster", mcp.Enum(values...), mcp.Description(desc))
+}
+
+// clusterHandler runs the handler against the cluster selected by the cluster argument,
+// or against all clusters concurrently for fan-out tools.
+func clusterHandler(next server.ToolHandlerFunc, fanOut bool) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.GetString("cluster", "")
+		if fanOut && name == AllClusters {
+			return fanOutHandler(ctx, request, next), nil
+		}
+		clusterCtx, err := withCluster(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(clusterCtx, request)
+	}
+}
+
+// fanOutHandler runs the handler against all clusters concurrently.
+// Failures are reported per cluster, the result is only an error if all clusters failed.
+func fanOutHandler(ctx context.Context, request mcp.CallToolRequest, next server.ToolHandlerFunc) *mcp.CallToolResult {
+	results := make([]ClusterResult, len(clusterNames))
+	var wg sync.WaitGroup
+	for i, name := range clusterNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = callCluster(ctx, request, next, name)
+		}()
+	}
+	wg.Wait()
+
+	fanOut := FanOutResult{Results: results}
+	for i := range results {
+		if results[i].Error != "" {
+			fanOut.Failed++
+		} else {
+			fanOut.Succeeded++
+		}
+	}
+
+	jsonBytes, err := json.Marshal(fanOut)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrMarshalFailed, err))
+	}
+	if fanOut.Succeeded == 0 {
+		return mcp.NewToolResultError(string(jsonBytes))
+	}
+	return mcp.NewToolResultText(string(jsonBytes))
+}
+
+// callCluster runs the handler against a single cluster and tags the result with the cluster name.
+func callCluster(ctx context.Context, request mcp.CallToolRequest, next server.ToolHandlerFunc, name string) ClusterResult {
+	clusterResult := ClusterResult{Cluster: name}
+	clusterCtx, err := withCluster(ctx, name)
+	if err != nil {
+		clusterResult.Error = err.Error()
+		return clusterResult
+	}
+
+	result, err := next(clusterCtx, request)
+	switch {
+	case err != nil:
+		clusterResult.Error = err.Error()
+	case result == nil:
+	case result.IsError:
+		clusterResult.Error = resultText(result)
+	default:
+		text := resultText(result)
+		if json.Valid([]byte(text)) {
+			clusterResult.Result = json.RawMessage(text)
+		} else {
+			clusterResult.Result = text
+		}
+	}
+	return clusterResult
+}
+
+// resultText joins the text contents of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
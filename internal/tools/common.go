@@ -33,20 +33,34 @@ const (
 	nowKeyword      = "now"
 )
 
+// Time ranges queried when a tool call specifies none, see SetDefaultDurations
+var (
+	defaultQueryDuration = DefaultDuration * time.Minute
+	defaultTraceDuration = DefaultTraceDuration
+)
+
+// SetDefaultDurations sets the time ranges queried when a tool call specifies none,
+// for metrics, logs, alarms, events and topology, and for traces. Zero keeps the built-in default.
+func SetDefaultDurations(query, trace time.Duration) {
+	if query > 0 {
+		defaultQueryDuration = max(query, time.Minute)
+	}
+	if trace > 0 {
+		defaultTraceDuration = trace
+	}
+}
+
+// configuredDurationMinutes returns the default time range of queries without a duration in minutes.
+func configuredDurationMinutes() int {
+	return int(defaultQueryDuration / time.Minute)
+}
+
 // Error messages
 const (
 	ErrMissingDuration = "missing required parameter: duration"
 	ErrMarshalFailed   = "failed to marshal result: %v"
 )
 
-// FinalizeURL ensures the URL ends with "/graphql".
-func FinalizeURL(urlStr string) string {
-	if !strings.HasSuffix(urlStr, "/graphql") {
-		urlStr = strings.TrimRight(urlStr, "/") + "/graphql"
-	}
-	return urlStr
-}
-
 // FormatTimeByStep formats time according to step granularity
 func FormatTimeByStep(t time.Time, step api.Step) string {
 	switch step {
@@ -63,6 +77,36 @@ func FormatTimeByStep(t time.Time, step api.Step) string {
 	}
 }
 
+// parseTimeByStep parses a time formatted by FormatTimeByStep in the local time zone
+func parseTimeByStep(s string, step api.Step) (time.Time, error) {
+	layout := "2006-01-02 15:04:05"
+	switch step {
+	case api.StepDay:
+		layout = "2006-01-02"
+	case api.StepHour:
+		layout = "2006-01-02 15"
+	case api.StepMinute:
+		layout = "2006-01-02 1504"
+	case api.StepSecond:
+		layout = "2006-01-02 150405"
+	}
+	return time.ParseInLocation(layout, s, time.Local)
+}
+
+// stepLength returns the length of a step, a time of a duration covers a whole step
+func stepLength(step api.Step) time.Duration {
+	switch step {
+	case api.StepDay:
+		return 24 * time.Hour
+	case api.StepHour:
+		return time.Hour
+	case api.StepMinute:
+		return time.Minute
+	default:
+		return time.Second
+	}
+}
+
 // ParseDuration converts duration string to api.Duration
 func ParseDuration(durationStr string, coldStage bool) api.Duration {
 	now := time.Now().In(time.Local)
@@ -216,27 +260,31 @@ func parseAbsoluteTime(timeStr string) (time.Time, bool) {
 
 // parseTimeString parses a time string (start or end)
 func parseTimeString(timeStr string, defaultTime time.Time) time.Time {
-	now := time.Now().In(time.Local)
-
 	if timeStr == "" {
 		return defaultTime
 	}
+	if parsed, ok := parseExactTime(timeStr); ok {
+		return parsed
+	}
+	return defaultTime
+}
+
+// parseExactTime parses "now", a time relative to now like "-30m" or "10m", or an absolute time.
+// Unlike parseTimeString, it reports malformed time strings instead of falling back to a default.
+func parseExactTime(timeStr string) (time.Time, bool) {
+	now := time.Now().In(time.Local)
 
 	if strings.EqualFold(timeStr, nowKeyword) {
-		return now
+		return now, true
 	}
 
 	// Try relative time like "-30m", "-1h"
 	if duration, err := time.ParseDuration(timeStr); err == nil {
-		return now.Add(duration)
+		return now.Add(duration), true
 	}
 
 	// Try absolute time
-	if parsed, ok := parseAbsoluteTime(timeStr); ok {
-		return parsed
-	}
-
-	return defaultTime
+	return parseAbsoluteTime(timeStr)
 }
 
 // parseStartEndTimes parses start and end time strings
@@ -18,18 +18,12 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
-	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/spf13/viper"
 	api "skywalking.apache.org/repo/goapi/query"
 )
 
@@ -71,7 +65,7 @@ func queryEvents(ctx context.Context, req *EventQueryRequest) (*mcp.CallToolResu
 	if req.Duration != "" {
 		duration = ParseDuration(req.Duration, false)
 	} else {
-		duration = BuildDuration(req.Start, req.End, "", false, DefaultDuration)
+		duration = BuildDuration(req.Start, req.End, "", false, configuredDurationMinutes())
 	}
 
 	// Build pagination
@@ -140,9 +134,9 @@ func queryEvents(ctx context.Context, req *EventQueryRequest) (*mcp.CallToolResu
 		variables["type"] = req.Type
 	}
 
-	result, err := executeGraphQLForEvent(ctx, viper.GetString("url"), query, variables)
+	result, err := executeGraphQL(ctx, query, variables)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to query events: %v", err)), nil
+		return oapErrorResult("query events", err), nil
 	}
 
 	jsonBytes, err := json.Marshal(result.Data)
@@ -152,55 +146,6 @@ func queryEvents(ctx context.Context, req *EventQueryRequest) (*mcp.CallToolResu
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
-// executeGraphQLForEvent executes a GraphQL query for event data
-func executeGraphQLForEvent(ctx context.Context, url, query string, variables map[string]interface{}) (*GraphQLResponse, error) {
-	url = FinalizeURL(url)
-
-	reqBody := GraphQLRequest{
-		Query:     query,
-		Variables: variables,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP request failed with status: %d, body: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var graphqlResp GraphQLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
-		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
-	}
-
-	if len(graphqlResp.Errors) > 0 {
-		var errorMsgs []string
-		for _, err := range graphqlResp.Errors {
-			errorMsgs = append(errorMsgs, err.Message)
-		}
-		return nil, fmt.Errorf("GraphQL errors: %s", strings.Join(errorMsgs, ", "))
-	}
-
-	return &graphqlResp, nil
-}
-
 // EventQueryTool is a tool for querying events
 var EventQueryTool = NewTool[EventQueryRequest, *mcp.CallToolResult](
 	"query_events",
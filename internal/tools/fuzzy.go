@@ -0,0 +1,120 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"sort"
+	"strings"
+)
+
+// minSimilarity is the similarity below which a candidate is not suggested
+const minSimilarity = 0.5
+
+// groupSeparator separates the group from the name of a service, e.g. agent::order-service
+const groupSeparator = "::"
+
+// suggest ranks the candidates by similarity to the name and returns the best ones.
+// Matching is case-insensitive, and names are also compared without their group prefix,
+// so that "order-svc" suggests "agent::order-service".
+func suggest(name string, candidates []string) []string {
+	type match struct {
+		name  string
+		score float64
+	}
+	seen := make(map[string]bool)
+	var matches []match
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		if score := similarity(name, candidate); score >= minSimilarity {
+			matches = append(matches, match{name: candidate, score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := make([]string, 0, min(len(matches), maxSuggestions))
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// similarity scores how close the candidate is to the name, from 0 (unrelated) to 1 (equal ignoring case).
+func similarity(name, candidate string) float64 {
+	name, candidate = strings.ToLower(name), strings.ToLower(candidate)
+	score := editSimilarity(name, candidate)
+
+	// compare the names without group prefix, slightly below a match of the full names
+	shortName, shortCandidate := stripGroup(name), stripGroup(candidate)
+	if shortName != name || shortCandidate != candidate {
+		score = max(score, 0.95*editSimilarity(shortName, shortCandidate))
+	}
+
+	// a name contained in the candidate is likely a part of it, e.g. "orders" of "GET:/api/orders"
+	if shortName != "" && strings.Contains(shortCandidate, shortName) {
+		score = max(score, 0.6+0.3*float64(len(shortName))/float64(len(shortCandidate)))
+	}
+	return score
+}
+
+// stripGroup removes the group prefix of a service name.
+func stripGroup(name string) string {
+	if i := strings.LastIndex(name, groupSeparator); i >= 0 {
+		return name[i+len(groupSeparator):]
+	}
+	return name
+}
+
+// editSimilarity is one minus the edit distance of the strings relative to the longer one.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein computes the edit distance of the strings with a single row of the distance matrix.
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			above := row[j]
+			row[j] = min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = above
+		}
+	}
+	return row[len(b)]
+}
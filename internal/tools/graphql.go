@@ -17,16 +17,49 @@
 
 package tools
 
-// GraphQLRequest represents a GraphQL request
-type GraphQLRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables,omitempty"`
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/apache/skywalking-mcp/internal/oap"
+)
+
+// executeGraphQL executes a GraphQL query against the SkyWalking OAP of the current request.
+// The endpoint, insecure flag and credentials are all resolved from the context.
+func executeGraphQL(ctx context.Context, query string, variables map[string]interface{}) (*oap.Response, error) {
+	return oap.Default().Execute(ctx, query, variables)
+}
+
+// queryGraphQL executes a GraphQL query and decodes the data of the response into out.
+func queryGraphQL(ctx context.Context, query string, variables map[string]interface{}, out any) error {
+	resp, err := executeGraphQL(ctx, query, variables)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode GraphQL data: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode GraphQL data: %w", err)
+	}
+	return nil
+}
+
+// oapErrorHints tells the agent how to react to each kind of OAP failure.
+var oapErrorHints = map[oap.ErrorKind]string{
+	oap.KindBadRequest:   "OAP rejected the request, check the arguments before retrying",
+	oap.KindUnauthorized: "OAP rejected the credentials, retrying will not help",
+	oap.KindUnavailable:  "OAP is unreachable or failing, retry later",
+	oap.KindQueryFailed:  "OAP failed to execute the query",
 }
 
-// GraphQLResponse represents a GraphQL response
-type GraphQLResponse struct {
-	Data   interface{} `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors,omitempty"`
+// oapErrorResult converts an OAP failure into a tool error result, keeping its classification.
+func oapErrorResult(action string, err error) *mcp.CallToolResult {
+	kind := oap.KindOf(err)
+	return mcp.NewToolResultError(fmt.Sprintf("failed to %s: %v [kind: %s, retryable: %t] %s",
+		action, err, kind, oap.IsRetryable(err), oapErrorHints[kind]))
 }
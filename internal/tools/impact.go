@@ -0,0 +1,333 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// Impact analysis defaults
+const (
+	DefaultImpactDepth = 2
+)
+
+// Directions of the impact analysis
+const (
+	ImpactUpstream   = "upstream"
+	ImpactDownstream = "downstream"
+	ImpactBoth       = "both"
+)
+
+// Error messages
+const (
+	ErrInvalidImpactDirection = "invalid direction %q, must be one of: upstream, downstream, both"
+)
+
+// ImpactRequest defines the parameters for the impact analysis
+type ImpactRequest struct {
+	ServiceID string `json:"service_id"`
+	Direction string `json:"direction,omitempty"`
+	Depth     int    `json:"depth,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+}
+
+// ImpactAlarm is an alarm fired for a service in the analyzed time range
+type ImpactAlarm struct {
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	StartTime string `json:"start_time"`
+}
+
+// ImpactedService is a service reached from the analyzed service through the topology
+type ImpactedService struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Hops is the distance from the analyzed service
+	Hops int `json:"hops"`
+	// Via is the next service on the path to the analyzed service
+	Via string `json:"via,omitempty"`
+	// CPM is the calls per minute between the service and the next service on the path
+	CPM    *float64       `json:"cpm,omitempty"`
+	Alarms []*ImpactAlarm `json:"alarms,omitempty"`
+}
+
+// ImpactAnalysis is the blast radius of a service: the services calling it and the services it calls
+type ImpactAnalysis struct {
+	Service    *ImpactedService   `json:"service"`
+	Upstream   []*ImpactedService `json:"upstream,omitempty"`
+	Downstream []*ImpactedService `json:"downstream,omitempty"`
+	// AlarmingServices is the number of affected services with alarms
+	AlarmingServices int `json:"alarming_services"`
+	// AlarmsError is set when the alarms could not be queried, the services are not flagged then
+	AlarmsError string `json:"alarms_error,omitempty"`
+}
+
+// impactWalk is the breadth-first walk of the topology in one direction
+type impactWalk struct {
+	upstream bool
+	services map[string]*ImpactedService
+	// calls are the calls linking every reached service to the next service on the path
+	calls    map[string]*TopologyCall
+	frontier []string
+}
+
+// analyzeImpact walks the service topology upstream and downstream from a service,
+// ranks the affected services by traffic and flags the services with alarms
+func analyzeImpact(ctx context.Context, req *ImpactRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceID == "" {
+		return mcp.NewToolResultError(ErrMissingServiceID), nil
+	}
+	if req.Direction == "" {
+		req.Direction = ImpactBoth
+	}
+	if req.Direction != ImpactUpstream && req.Direction != ImpactDownstream && req.Direction != ImpactBoth {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidImpactDirection, req.Direction)), nil
+	}
+	if req.Depth == 0 {
+		req.Depth = DefaultImpactDepth
+	}
+	if req.Depth < 1 || req.Depth > MaxTopologyDepth {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidTopologyDepth, MaxTopologyDepth)), nil
+	}
+
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := topologyDuration(req.Duration)
+	var walks []*impactWalk
+	if req.Direction != ImpactDownstream {
+		walks = append(walks, newImpactWalk(serviceID, true))
+	}
+	if req.Direction != ImpactUpstream {
+		walks = append(walks, newImpactWalk(serviceID, false))
+	}
+	names, err := walkImpact(ctx, serviceID, walks, req.Depth, duration)
+	if err != nil {
+		return oapErrorResult("query the topology of the impacted services", err), nil
+	}
+
+	// the traffic of the calls on the paths ranks the affected services
+	calls := make(map[string]*TopologyCall)
+	for _, walk := range walks {
+		for _, call := range walk.calls {
+			calls[call.ID] = call
+		}
+	}
+	if err := setCallTraffic(ctx, calls, duration); err != nil {
+		return oapErrorResult("query the traffic of the impacted services", err), nil
+	}
+
+	analysis := &ImpactAnalysis{Service: &ImpactedService{ID: serviceID, Name: names[serviceID]}}
+	for _, walk := range walks {
+		services := walk.ranked(names)
+		if walk.upstream {
+			analysis.Upstream = services
+		} else {
+			analysis.Downstream = services
+		}
+	}
+	flagAlarms(ctx, analysis, duration)
+	return toolResultJSON(analysis), nil
+}
+
+// newImpactWalk starts a walk from the analyzed service
+func newImpactWalk(serviceID string, upstream bool) *impactWalk {
+	return &impactWalk{
+		upstream: upstream,
+		services: map[string]*ImpactedService{serviceID: {ID: serviceID}},
+		calls:    make(map[string]*TopologyCall),
+		frontier: []string{serviceID},
+	}
+}
+
+// walkImpact advances the walks hop by hop. Every hop is a single query for the calls of the
+// services reached by the previous hop, in any direction. It returns the names of all services seen.
+func walkImpact(ctx context.Context, serviceID string, walks []*impactWalk, depth int, duration api.Duration) (map[string]string, error) {
+	names := make(map[string]string)
+	// calls of the services already queried, by source and by target
+	bySource := make(map[string][]*TopologyCall)
+	byTarget := make(map[string][]*TopologyCall)
+	seen := make(map[string]bool)
+	queried := make(map[string]bool)
+
+	for hop := 1; hop <= depth; hop++ {
+		var serviceIDs []string
+		for _, walk := range walks {
+			for _, id := range walk.frontier {
+				if !queried[id] {
+					queried[id] = true
+					serviceIDs = append(serviceIDs, id)
+				}
+			}
+		}
+		if len(serviceIDs) > 0 {
+			var data struct {
+				Topology api.Topology `json:"topology"`
+			}
+			variables := map[string]interface{}{"serviceIds": serviceIDs, "duration": duration}
+			if err := queryGraphQL(ctx, servicesTopologyQuery, variables, &data); err != nil {
+				return nil, err
+			}
+			for _, node := range data.Topology.Nodes {
+				names[node.ID] = node.Name
+			}
+			for _, c := range data.Topology.Calls {
+				if seen[c.ID] {
+					continue
+				}
+				seen[c.ID] = true
+				call := topologyCall(c)
+				bySource[call.Source] = append(bySource[call.Source], call)
+				byTarget[call.Target] = append(byTarget[call.Target], call)
+			}
+		}
+
+		for _, walk := range walks {
+			walk.advance(hop, bySource, byTarget)
+		}
+	}
+
+	if _, ok := names[serviceID]; !ok {
+		names[serviceID], _, _ = ParseServiceID(serviceID)
+	}
+	return names, nil
+}
+
+// advance reaches the callers, or the callees, of the services of the frontier
+func (w *impactWalk) advance(hop int, bySource, byTarget map[string][]*TopologyCall) {
+	var frontier []string
+	for _, id := range w.frontier {
+		calls, next := bySource[id], func(call *TopologyCall) string { return call.Target }
+		if w.upstream {
+			calls, next = byTarget[id], func(call *TopologyCall) string { return call.Source }
+		}
+		for _, call := range calls {
+			nextID := next(call)
+			if _, ok := w.services[nextID]; ok {
+				continue
+			}
+			w.services[nextID] = &ImpactedService{ID: nextID, Hops: hop, Via: id}
+			w.calls[nextID] = call
+			frontier = append(frontier, nextID)
+		}
+	}
+	w.frontier = frontier
+}
+
+// ranked returns the reached services ordered by the traffic of the call linking them to the path,
+// the services without traffic last, then by hops and name
+func (w *impactWalk) ranked(names map[string]string) []*ImpactedService {
+	services := make([]*ImpactedService, 0, len(w.services))
+	for id, service := range w.services {
+		if service.Hops == 0 {
+			continue
+		}
+		service.Name = names[id]
+		service.Via = names[service.Via]
+		service.CPM = w.calls[id].CPM
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		a, b := services[i], services[j]
+		if (a.CPM == nil) != (b.CPM == nil) {
+			return a.CPM != nil
+		}
+		if a.CPM != nil && *a.CPM != *b.CPM {
+			return *a.CPM > *b.CPM
+		}
+		if a.Hops != b.Hops {
+			return a.Hops < b.Hops
+		}
+		return a.Name < b.Name
+	})
+	return services
+}
+
+// flagAlarms attaches the alarms fired in the time range to the analyzed and the affected services
+func flagAlarms(ctx context.Context, analysis *ImpactAnalysis, duration api.Duration) {
+	alarms, err := queryServiceAlarms(ctx, duration)
+	if err != nil {
+		analysis.AlarmsError = err.Error()
+		return
+	}
+
+	flag := func(service *ImpactedService) bool {
+		for _, msg := range alarms[service.ID] {
+			service.Alarms = append(service.Alarms, &ImpactAlarm{
+				Name:      msg.Name,
+				Message:   msg.Message,
+				StartTime: time.UnixMilli(msg.StartTime).Format(time.RFC3339),
+			})
+		}
+		return len(service.Alarms) > 0
+	}
+	flag(analysis.Service)
+	alarming := make(map[string]bool)
+	for _, services := range [][]*ImpactedService{analysis.Upstream, analysis.Downstream} {
+		for _, service := range services {
+			if flag(service) {
+				alarming[service.ID] = true
+			}
+		}
+	}
+	analysis.AlarmingServices = len(alarming)
+}
+
+// ImpactTool is a tool for analyzing the blast radius of a service
+var ImpactTool = NewTool[ImpactRequest, *mcp.CallToolResult](
+	"analyze_impact",
+	`Analyze the blast radius of a service: which services are affected if it degrades.
+
+This tool walks the service topology breadth-first from a service, upstream to the services
+calling it directly or indirectly, and downstream to the services it depends on.
+
+Results:
+- upstream: services affected when the service degrades, e.g. slow or failing callers
+- downstream: services the service depends on, candidates for the root cause of its degradation
+- Every service has its distance in hops, the next service on the path (via) and the calls per
+  minute (cpm) between them, services are ranked by cpm
+- Services with alarms fired in the time range are flagged with the alarms, and counted in alarming_services
+
+Examples:
+- {"service_id": "agent::payment-service"}: Who is affected if payment-service degrades, 2 hops both ways
+- {"service_id": "agent::payment-service", "direction": "upstream", "depth": 4}: All callers up to 4 hops
+- {"service_id": "agent::gateway", "direction": "downstream", "duration": "-15m"}: Dependencies of the gateway and their recent alarms`,
+	analyzeImpact,
+	mcp.WithTitleAnnotation("Analyze service impact"),
+	mcp.WithString("service_id", mcp.Required(),
+		mcp.Description("Service ID or name to analyze."),
+	),
+	mcp.WithString("direction",
+		mcp.Enum(ImpactUpstream, ImpactDownstream, ImpactBoth),
+		mcp.Description("Walk to the callers (upstream), to the callees (downstream), or both. Default is both."),
+	),
+	mcp.WithNumber("depth",
+		mcp.Description(fmt.Sprintf("Number of hops to walk, from 1 to %d. Default is %d.", MaxTopologyDepth, DefaultImpactDepth)),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Time range of the traffic and the alarms. Examples: \"-15m\", \"-1h\". Default is last 30 minutes."),
+	),
+)
@@ -55,7 +55,7 @@ type LogQueryRequest struct {
 
 // buildLogQueryCondition builds the log query condition from request parameters
 func buildLogQueryCondition(req *LogQueryRequest) *api.LogQueryCondition {
-	duration := BuildDuration(req.Start, req.End, req.Step, req.Cold, DefaultDuration)
+	duration := BuildDuration(req.Start, req.End, req.Step, req.Cold, configuredDurationMinutes())
 
 	var tags []*api.LogTag
 	for _, t := range req.Tags {
@@ -82,6 +82,9 @@ func buildLogQueryCondition(req *LogQueryRequest) *api.LogQueryCondition {
 
 // queryLogs queries logs from SkyWalking OAP
 func queryLogs(ctx context.Context, req *LogQueryRequest) (*mcp.CallToolResult, error) {
+	if err := resolveEntityIDs(ctx, &req.ServiceID, &req.ServiceInstanceID, &req.EndpointID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	cond := buildLogQueryCondition(req)
 
 	logs, err := swlog.Logs(ctx, cond)
@@ -112,9 +115,9 @@ Examples:
 - {"trace_id": "abc123..."}: Query logs related to a specific trace
 - {"tags": [{"key": "level", "value": "ERROR"}], "cold": true}: Query error logs from cold storage`,
 	queryLogs,
-	mcp.WithString("service_id", mcp.Description("Service ID to filter logs.")),
-	mcp.WithString("service_instance_id", mcp.Description("Service instance ID to filter logs.")),
-	mcp.WithString("endpoint_id", mcp.Description("Endpoint ID to filter logs.")),
+	mcp.WithString("service_id", mcp.Description("Service ID or name to filter logs.")),
+	mcp.WithString("service_instance_id", mcp.Description("Service instance ID or name to filter logs. Names require service_id.")),
+	mcp.WithString("endpoint_id", mcp.Description("Endpoint ID or name to filter logs. Names require service_id.")),
 	mcp.WithString("trace_id", mcp.Description("Related trace ID.")),
 	mcp.WithArray("tags", mcp.Description("Array of log tags, each with key and value.")),
 	mcp.WithString("start", mcp.Description("Start time for the query.")),
@@ -0,0 +1,423 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+)
+
+// AddMetadataTools registers metadata discovery tools with the MCP server
+func AddMetadataTools(srv *server.MCPServer) {
+	ListLayersTool.Register(srv)
+	ListServicesTool.Register(srv)
+	ListInstancesTool.Register(srv)
+	SearchEndpointsTool.Register(srv)
+	ListProcessesTool.Register(srv)
+}
+
+// Error messages
+const (
+	ErrFailedToListLayers      = "failed to list layers: %v"
+	ErrFailedToListServices    = "failed to list services: %v"
+	ErrFailedToListInstances   = "failed to list instances: %v"
+	ErrFailedToSearchEndpoints = "failed to search endpoints: %v"
+	ErrFailedToListProcesses   = "failed to list processes: %v"
+	ErrMissingInstanceID       = "missing required parameter: service_instance_id"
+)
+
+// ListLayersRequest defines the parameters for listing layers
+type ListLayersRequest struct{}
+
+// ListServicesRequest defines the parameters for listing services
+type ListServicesRequest struct {
+	Layer    string `json:"layer,omitempty"`
+	Keyword  string `json:"keyword,omitempty"`
+	PageNum  int    `json:"page_num,omitempty"`
+	PageSize int    `json:"page_size,omitempty"`
+}
+
+// ListInstancesRequest defines the parameters for listing the instances of a service
+type ListInstancesRequest struct {
+	ServiceID string `json:"service_id"`
+	Keyword   string `json:"keyword,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	PageNum   int    `json:"page_num,omitempty"`
+	PageSize  int    `json:"page_size,omitempty"`
+}
+
+// SearchEndpointsRequest defines the parameters for searching the endpoints of a service
+type SearchEndpointsRequest struct {
+	ServiceID string `json:"service_id"`
+	Keyword   string `json:"keyword,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	PageNum   int    `json:"page_num,omitempty"`
+	PageSize  int    `json:"page_size,omitempty"`
+}
+
+// ListProcessesRequest defines the parameters for listing the processes of a service instance
+type ListProcessesRequest struct {
+	ServiceID         string `json:"service_id,omitempty"`
+	ServiceInstanceID string `json:"service_instance_id"`
+	Keyword           string `json:"keyword,omitempty"`
+	Duration          string `json:"duration,omitempty"`
+	PageNum           int    `json:"page_num,omitempty"`
+	PageSize          int    `json:"page_size,omitempty"`
+}
+
+// Page is a page of a listing
+type Page[T any] struct {
+	Items    []T  `json:"items"`
+	PageNum  int  `json:"page_num"`
+	PageSize int  `json:"page_size"`
+	Total    *int `json:"total,omitempty"`
+	HasMore  bool `json:"has_more"`
+}
+
+// paginate returns the requested page of all items
+func paginate[T any](items []T, pageNum, pageSize int) Page[T] {
+	pagination := BuildPagination(pageNum, pageSize)
+	pageNum, pageSize = *pagination.PageNum, pagination.PageSize
+
+	total := len(items)
+	start := min((pageNum-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	return Page[T]{
+		Items:    items[start:end],
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Total:    &total,
+		HasMore:  end < total,
+	}
+}
+
+// matchesKeyword reports whether the name contains the keyword, ignoring case
+func matchesKeyword(name, keyword string) bool {
+	return keyword == "" || strings.Contains(strings.ToLower(name), strings.ToLower(keyword))
+}
+
+// toolResultJSON marshals the result of a tool
+func toolResultJSON(v any) *mcp.CallToolResult {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrMarshalFailed, err))
+	}
+	return mcp.NewToolResultText(string(jsonBytes))
+}
+
+// listLayers lists the layers known to OAP
+func listLayers(ctx context.Context, _ *ListLayersRequest) (*mcp.CallToolResult, error) {
+	layers, err := metadata.ListLayers(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListLayers, err)), nil
+	}
+	sort.Strings(layers)
+	return toolResultJSON(layers), nil
+}
+
+// listAllServices lists the services of a layer, or of all layers if layer is empty
+func listAllServices(ctx context.Context, layer string) ([]api.Service, error) {
+	layers := []string{layer}
+	if layer == "" {
+		var err error
+		if layers, err = metadata.ListLayers(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// a service is listed in every layer it belongs to
+	seen := make(map[string]bool)
+	var services []api.Service
+	for _, l := range layers {
+		layerServices, err := metadata.ListLayerService(ctx, l)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range layerServices {
+			if !seen[service.ID] {
+				seen[service.ID] = true
+				services = append(services, service)
+			}
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+// listServices lists services, filtered by layer and keyword
+func listServices(ctx context.Context, req *ListServicesRequest) (*mcp.CallToolResult, error) {
+	services, err := listAllServices(ctx, strings.ToUpper(req.Layer))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListServices, err)), nil
+	}
+
+	matched := make([]api.Service, 0, len(services))
+	for _, service := range services {
+		if matchesKeyword(service.Name, req.Keyword) {
+			matched = append(matched, service)
+		}
+	}
+	return toolResultJSON(paginate(matched, req.PageNum, req.PageSize)), nil
+}
+
+// listInstances lists the instances of a service, filtered by keyword
+func listInstances(ctx context.Context, req *ListInstancesRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceID == "" {
+		return mcp.NewToolResultError(ErrMissingServiceID), nil
+	}
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := BuildDuration("", "", "", false, configuredDurationMinutes())
+	if req.Duration != "" {
+		duration = ParseDuration(req.Duration, false)
+	}
+	instances, err := metadata.Instances(ctx, serviceID, duration)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListInstances, err)), nil
+	}
+
+	matched := make([]api.ServiceInstance, 0, len(instances))
+	for _, instance := range instances {
+		if matchesKeyword(instance.Name, req.Keyword) {
+			matched = append(matched, instance)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	return toolResultJSON(paginate(matched, req.PageNum, req.PageSize)), nil
+}
+
+// searchEndpoints searches the endpoints of a service by keyword.
+// OAP only supports a limit, so the endpoints up to the requested page are fetched.
+func searchEndpoints(ctx context.Context, req *SearchEndpointsRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceID == "" {
+		return mcp.NewToolResultError(ErrMissingServiceID), nil
+	}
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pagination := BuildPagination(req.PageNum, req.PageSize)
+	pageNum, pageSize := *pagination.PageNum, pagination.PageSize
+
+	var duration *api.Duration
+	if req.Duration != "" {
+		d := ParseDuration(req.Duration, false)
+		duration = &d
+	}
+	// fetch one more endpoint to know whether there is a next page
+	limit := pageNum*pageSize + 1
+	endpoints, err := metadata.SearchEndpoints(ctx, serviceID, req.Keyword, limit, duration)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToSearchEndpoints, err)), nil
+	}
+
+	start := min((pageNum-1)*pageSize, len(endpoints))
+	end := min(start+pageSize, len(endpoints))
+	return toolResultJSON(Page[api.Endpoint]{
+		Items:    endpoints[start:end],
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		HasMore:  len(endpoints) > end,
+	}), nil
+}
+
+// listProcesses lists the processes of a service instance detected by the eBPF agent.
+func listProcesses(ctx context.Context, req *ListProcessesRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceInstanceID == "" {
+		return mcp.NewToolResultError(ErrMissingInstanceID), nil
+	}
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	instanceID, err := ResolveInstanceID(ctx, serviceID, req.ServiceInstanceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := BuildDuration("", "", "", false, configuredDurationMinutes())
+	if req.Duration != "" {
+		duration = ParseDuration(req.Duration, false)
+	}
+	processes, err := metadata.Processes(ctx, instanceID, duration)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListProcesses, err)), nil
+	}
+
+	matched := make([]api.Process, 0, len(processes))
+	for _, process := range processes {
+		if matchesKeyword(process.Name, req.Keyword) {
+			matched = append(matched, process)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	return toolResultJSON(paginate(matched, req.PageNum, req.PageSize)), nil
+}
+
+// ListLayersTool is a tool for listing layers
+var ListLayersTool = NewTool[ListLayersRequest, *mcp.CallToolResult](
+	"list_layers",
+	`List the layers known to SkyWalking OAP.
+
+A layer is the technology or platform of a service, e.g. GENERAL for services monitored by
+language agents, MESH for service mesh, K8S_SERVICE for Kubernetes or MYSQL for databases.
+
+Workflow:
+1. Use this tool to see which layers have services
+2. Use list_services with a layer to find the services of that layer`,
+	listLayers,
+	mcp.WithTitleAnnotation("List layers"),
+)
+
+// ListServicesTool is a tool for listing services
+var ListServicesTool = NewTool[ListServicesRequest, *mcp.CallToolResult](
+	"list_services",
+	`List the services known to SkyWalking OAP, with their IDs.
+
+Use this tool to find the service_id required by query_traces, query_logs and the topology tools,
+instead of guessing it.
+
+Examples:
+- {}: All services of all layers
+- {"layer": "GENERAL"}: Services monitored by language agents
+- {"keyword": "order"}: Services whose name contains "order"
+- {"layer": "MESH", "page_num": 2, "page_size": 50}: Second page of mesh services`,
+	listServices,
+	mcp.WithTitleAnnotation("List services"),
+	mcp.WithString("layer",
+		mcp.Description("Layer of the services, e.g. GENERAL, MESH, K8S_SERVICE. Default is all layers, see list_layers."),
+	),
+	mcp.WithString("keyword",
+		mcp.Description("Only services whose name contains the keyword, case-insensitive."),
+	),
+	mcp.WithNumber("page_num",
+		mcp.Description("Page number, starting from 1. Default is 1."),
+	),
+	mcp.WithNumber("page_size",
+		mcp.Description("Number of services per page. Default is 15."),
+	),
+)
+
+// ListInstancesTool is a tool for listing the instances of a service
+var ListInstancesTool = NewTool[ListInstancesRequest, *mcp.CallToolResult](
+	"list_instances",
+	`List the instances of a service, with their IDs, languages and attributes.
+
+Use this tool to find the service_instance_id required by query_traces and query_logs.
+Only instances that were alive during the duration are listed.
+
+Examples:
+- {"service_id": "c2VydmljZQ==.1"}: Instances alive in the default time range
+- {"service_id": "c2VydmljZQ==.1", "duration": "-24h"}: Instances alive in the past day
+- {"service_id": "c2VydmljZQ==.1", "keyword": "pod-7"}: Instances whose name contains "pod-7"`,
+	listInstances,
+	mcp.WithTitleAnnotation("List service instances"),
+	mcp.WithString("service_id", mcp.Required(),
+		mcp.Description("Service ID or name, see list_services."),
+	),
+	mcp.WithString("keyword",
+		mcp.Description("Only instances whose name contains the keyword, case-insensitive."),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Time range in which instances were alive, e.g. \"-1h\", \"-24h\", \"7d\". Default is the configured default duration."),
+	),
+	mcp.WithNumber("page_num",
+		mcp.Description("Page number, starting from 1. Default is 1."),
+	),
+	mcp.WithNumber("page_size",
+		mcp.Description("Number of instances per page. Default is 15."),
+	),
+)
+
+// SearchEndpointsTool is a tool for searching the endpoints of a service
+var SearchEndpointsTool = NewTool[SearchEndpointsRequest, *mcp.CallToolResult](
+	"search_endpoints",
+	`Search the endpoints of a service by keyword, with their IDs.
+
+Use this tool to find the endpoint_id required by query_traces and the exact endpoint names
+used in MQE expressions and profiling tasks.
+
+Examples:
+- {"service_id": "c2VydmljZQ==.1"}: First endpoints of a service
+- {"service_id": "c2VydmljZQ==.1", "keyword": "/api/orders"}: Endpoints whose name contains "/api/orders"
+- {"service_id": "c2VydmljZQ==.1", "keyword": "GET", "page_num": 2}: Second page of matching endpoints`,
+	searchEndpoints,
+	mcp.WithTitleAnnotation("Search endpoints"),
+	mcp.WithString("service_id", mcp.Required(),
+		mcp.Description("Service ID or name, see list_services."),
+	),
+	mcp.WithString("keyword",
+		mcp.Description("Only endpoints whose name contains the keyword. Default is all endpoints."),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Only endpoints active in the time range, e.g. \"-1h\". Supported by OAP 10.2 and later. Default is no restriction."),
+	),
+	mcp.WithNumber("page_num",
+		mcp.Description("Page number, starting from 1. Default is 1."),
+	),
+	mcp.WithNumber("page_size",
+		mcp.Description("Number of endpoints per page. Default is 15."),
+	),
+)
+
+// ListProcessesTool is a tool for listing the processes of a service instance
+var ListProcessesTool = NewTool[ListProcessesRequest, *mcp.CallToolResult](
+	"list_processes",
+	`List the processes of a service instance, with their IDs, labels and attributes.
+
+Processes are detected by the SkyWalking Rover eBPF agent, usually in Kubernetes pods.
+Use this tool to find the process_name used by metrics of the Process and ProcessRelation scopes,
+and the instances to pass to get_process_topology.
+
+Examples:
+- {"service_id": "agent::order-service", "service_instance_id": "order-7d9f-x2v4"}: Processes of a pod
+- {"service_instance_id": "b3JkZXI=.1_cG9k", "keyword": "envoy"}: Processes whose name contains "envoy"`,
+	listProcesses,
+	mcp.WithTitleAnnotation("List processes"),
+	mcp.WithString("service_id",
+		mcp.Description("Service ID or name, required when service_instance_id is a name."),
+	),
+	mcp.WithString("service_instance_id", mcp.Required(),
+		mcp.Description("Service instance ID or name, see list_instances."),
+	),
+	mcp.WithString("keyword",
+		mcp.Description("Only processes whose name contains the keyword, case-insensitive."),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Time range in which processes were alive, e.g. \"-1h\", \"-24h\". Default is the configured default duration."),
+	),
+	mcp.WithNumber("page_num",
+		mcp.Description("Page number, starting from 1. Default is 1."),
+	),
+	mcp.WithNumber("page_size",
+		mcp.Description("Number of processes per page. Default is 15."),
+	),
+)
@@ -18,6 +18,7 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -219,12 +220,45 @@ func buildMetricsCondition(req *SingleMetricsRequest) *api.MetricsCondition {
 	return condition
 }
 
+// resolveMetricsEntity replaces service names or IDs of the entity by the names and normal flags known to OAP,
+// and verifies the endpoint names. Instance names are not verified, since instances of the past are gone.
+func resolveMetricsEntity(ctx context.Context, entity *api.Entity) error {
+	if entity.ServiceName != nil {
+		name, normal, err := resolveServiceName(ctx, *entity.ServiceName, "")
+		if err != nil {
+			return err
+		}
+		entity.ServiceName, entity.Normal = &name, &normal
+		if entity.EndpointName != nil {
+			if err := checkEndpointName(ctx, name, normal, *entity.EndpointName); err != nil {
+				return err
+			}
+		}
+	}
+	if entity.DestServiceName != nil {
+		name, normal, err := resolveServiceName(ctx, *entity.DestServiceName, "")
+		if err != nil {
+			return err
+		}
+		entity.DestServiceName, entity.DestNormal = &name, &normal
+		if entity.DestEndpointName != nil {
+			if err := checkEndpointName(ctx, name, normal, *entity.DestEndpointName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // querySingleMetrics queries single-value metrics
 func querySingleMetrics(ctx context.Context, req *SingleMetricsRequest) (*mcp.CallToolResult, error) {
 	if err := validateSingleMetricsRequest(req); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 	condition := buildMetricsCondition(req)
+	if err := resolveMetricsEntity(ctx, condition.Entity); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	var duration api.Duration
 	if req.Duration != "" {
@@ -250,6 +284,12 @@ func queryTopNMetrics(ctx context.Context, req *TopNMetricsRequest) (*mcp.CallTo
 	if err := validateTopNMetricsRequest(req); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	// the parent service may be given by name or ID, the condition needs its name and normal flag
+	serviceID, err := ResolveServiceID(ctx, cmp.Or(req.ServiceID, req.ServiceName))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	req.ServiceID = serviceID
 	condition := buildTopNCondition(req)
 
 	// Set default duration if none provided
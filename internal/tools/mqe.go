@@ -18,18 +18,14 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/spf13/viper"
 	api "skywalking.apache.org/repo/goapi/query"
 )
 
@@ -40,55 +36,6 @@ func AddMQETools(srv *server.MCPServer) {
 	MQEMetricsTypeTool.Register(srv)
 }
 
-// executeGraphQL executes a GraphQL query against SkyWalking OAP
-func executeGraphQL(ctx context.Context, url, query string, variables map[string]interface{}) (*GraphQLResponse, error) {
-	url = FinalizeURL(url)
-
-	reqBody := GraphQLRequest{
-		Query:     query,
-		Variables: variables,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP request failed with status: %d, body: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var graphqlResp GraphQLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&graphqlResp); err != nil {
-		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
-	}
-
-	if len(graphqlResp.Errors) > 0 {
-		var errorMsgs []string
-		for _, err := range graphqlResp.Errors {
-			errorMsgs = append(errorMsgs, err.Message)
-		}
-		return nil, fmt.Errorf("GraphQL errors: %s", strings.Join(errorMsgs, ", "))
-	}
-
-	return &graphqlResp, nil
-}
-
 // MQEExpressionRequest represents a request to execute MQE expression
 type MQEExpressionRequest struct {
 	Expression              string `json:"expression"`
@@ -123,114 +70,8 @@ type MQEMetricsTypeRequest struct {
 	MetricName string `json:"metric_name"`
 }
 
-// ListServicesRequest represents a request to list services
-type ListServicesRequest struct {
-	Layer string `json:"layer"`
-}
-
-// getServiceInfo queries service information using the specified layer
-func getServiceInfo(ctx context.Context, serviceName, layer string) bool {
-	if serviceName == "" {
-		return false
-	}
-
-	if layer == "" {
-		layer = "GENERAL"
-	}
-
-	normal, err := getServiceByName(ctx, serviceName, layer)
-	if err != nil {
-		return true
-	}
-	if normal != nil {
-		return *normal
-	}
-
-	return true
-}
-
-// getServiceByName tries to get service info directly by name in specified layer
-func getServiceByName(ctx context.Context, serviceName, layer string) (*bool, error) {
-	serviceID, err := findServiceID(ctx, serviceName, layer)
-	if err != nil {
-		return nil, fmt.Errorf("service not found in layer %s: %s", layer, serviceName)
-	}
-	if serviceID == "" {
-		return nil, fmt.Errorf("service not found in layer %s: %s", layer, serviceName)
-	}
-
-	query := `
-		query getService($serviceId: String!) {
-			service: getService(serviceId: $serviceId) {
-				id
-				name
-				normal
-				layers
-			}
-		}
-	`
-
-	variables := map[string]interface{}{
-		"serviceId": serviceID,
-	}
-
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get service details: %w", err)
-	}
-
-	if data, ok := result.Data.(map[string]interface{}); ok {
-		if service, ok := data["service"].(map[string]interface{}); ok {
-			if normal, ok := service["normal"].(bool); ok {
-				return &normal, nil
-			}
-		}
-	}
-
-	return nil, fmt.Errorf("invalid service data returned for: %s", serviceName)
-}
-
-// findServiceID finds service ID by name in a specific layer
-func findServiceID(ctx context.Context, serviceName, layer string) (string, error) {
-	query := `
-		query getServices($layer: String!) {
-			services: listServices(layer: $layer) {
-				id
-				name
-			}
-		}
-	`
-
-	variables := map[string]interface{}{
-		"layer": layer,
-	}
-
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
-	if err != nil {
-		return "", err
-	}
-
-	if data, ok := result.Data.(map[string]interface{}); ok {
-		if services, ok := data["services"].([]interface{}); ok {
-			for _, s := range services {
-				svc, ok := s.(map[string]interface{})
-				if !ok {
-					continue
-				}
-				if svc["name"] == serviceName {
-					if id, ok := svc["id"].(string); ok {
-						return id, nil
-					}
-				}
-			}
-		}
-	}
-
-	return "", nil
-}
-
 // buildMQEEntity builds the entity from request parameters
-func buildMQEEntity(ctx context.Context, req *MQEExpressionRequest) map[string]interface{} {
+func buildMQEEntity(ctx context.Context, req *MQEExpressionRequest) (map[string]interface{}, error) {
 	entity := make(map[string]interface{})
 
 	// Define a mapping of field names to their corresponding values
@@ -252,23 +93,47 @@ func buildMQEEntity(ctx context.Context, req *MQEExpressionRequest) map[string]i
 		}
 	}
 
-	// Handle special cases
-	if req.ServiceName != "" {
-		if req.Normal == nil {
-			normal := getServiceInfo(ctx, req.ServiceName, req.Layer)
-			entity["normal"] = normal
-		} else {
-			entity["normal"] = *req.Normal
-		}
-	} else if req.Normal != nil {
-		entity["normal"] = *req.Normal
+	// Services are identified by name and normal flag, both resolved from the given name or ID
+	if err := resolveMQEService(ctx, entity, "", req.ServiceName, req.Layer, req.Normal, req.EndpointName); err != nil {
+		return nil, err
+	}
+	if err := resolveMQEService(ctx, entity, "dest", req.DestServiceName, req.DestLayer, req.DestNormal, req.DestEndpointName); err != nil {
+		return nil, err
 	}
 
-	if req.DestNormal != nil {
-		entity["destNormal"] = *req.DestNormal
+	return entity, nil
+}
+
+// resolveMQEService sets the service name and normal flag of the entity, or of its destination when prefix is "dest".
+// An explicit normal flag takes precedence over the one of the resolved service.
+func resolveMQEService(ctx context.Context, entity map[string]interface{}, prefix, service, layer string, normal *bool, endpoint string) error {
+	nameKey, normalKey := "serviceName", "normal"
+	if prefix != "" {
+		nameKey, normalKey = prefix+"ServiceName", prefix+"Normal"
+	}
+
+	if service == "" {
+		if normal != nil {
+			entity[normalKey] = *normal
+		}
+		return nil
 	}
 
-	return entity
+	name, resolvedNormal, err := resolveServiceName(ctx, service, layer)
+	if err != nil {
+		return err
+	}
+	if endpoint != "" {
+		if err := checkEndpointName(ctx, name, resolvedNormal, endpoint); err != nil {
+			return err
+		}
+	}
+	entity[nameKey] = name
+	entity[normalKey] = resolvedNormal
+	if normal != nil {
+		entity[normalKey] = *normal
+	}
+	return nil
 }
 
 // executeMQEExpression executes MQE expression query
@@ -277,13 +142,16 @@ func executeMQEExpression(ctx context.Context, req *MQEExpressionRequest) (*mcp.
 		return mcp.NewToolResultError("expression is required"), nil
 	}
 
-	entity := buildMQEEntity(ctx, req)
+	entity, err := buildMQEEntity(ctx, req)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	var duration api.Duration
 	if req.Duration != "" {
 		duration = ParseDuration(req.Duration, req.Cold)
 	} else {
-		duration = BuildDuration(req.Start, req.End, req.Step, req.Cold, DefaultDuration)
+		duration = BuildDuration(req.Start, req.End, req.Step, req.Cold, configuredDurationMinutes())
 	}
 
 	// GraphQL query for MQE expression
@@ -352,9 +220,9 @@ func executeMQEExpression(ctx context.Context, req *MQEExpressionRequest) (*mcp.
 		}
 	}
 
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
+	result, err := executeGraphQL(ctx, query, variables)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute MQE expression: %v", err)), nil
+		return oapErrorResult("execute MQE expression", err), nil
 	}
 
 	jsonBytes, err := json.Marshal(result.Data)
@@ -364,6 +232,95 @@ func executeMQEExpression(ctx context.Context, req *MQEExpressionRequest) (*mcp.
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// mqeBatchSize bounds the number of expressions evaluated by a single GraphQL request
+const mqeBatchSize = 500
+
+// mqeBatchQuery is an MQE expression evaluated for an entity as part of a batch
+type mqeBatchQuery struct {
+	Expression string
+	Entity     map[string]interface{}
+}
+
+// mqeBatchValue is the value of an expression of a batch, nil when OAP has no data for it
+type mqeBatchValue struct {
+	Value *float64
+	Error string
+}
+
+// execMQEBatch evaluates the expressions with one aliased execExpression field each,
+// so that up to mqeBatchSize expressions take a single GraphQL request.
+// Every result is reduced to the average of the values of its first series,
+// which is the value itself for single value expressions.
+func execMQEBatch(ctx context.Context, queries []mqeBatchQuery, duration api.Duration) ([]mqeBatchValue, error) {
+	duration = metricsDuration(duration)
+	values := make([]mqeBatchValue, 0, len(queries))
+	for start := 0; start < len(queries); start += mqeBatchSize {
+		batch := queries[start:min(start+mqeBatchSize, len(queries))]
+
+		params := []string{"$duration: Duration!"}
+		variables := map[string]interface{}{"duration": duration}
+		var fields strings.Builder
+		for i, q := range batch {
+			params = append(params, fmt.Sprintf("$x%d: String!", i), fmt.Sprintf("$e%d: Entity!", i))
+			variables[fmt.Sprintf("x%d", i)] = q.Expression
+			variables[fmt.Sprintf("e%d", i)] = q.Entity
+			fmt.Fprintf(&fields, "\t\tm%d: execExpression(expression: $x%d, entity: $e%d, duration: $duration) { type error results { values { value } } }\n",
+				i, i, i)
+		}
+		query := fmt.Sprintf("query execExpressions(%s) {\n%s\t}", strings.Join(params, ", "), fields.String())
+
+		var data map[string]api.ExpressionResult
+		if err := queryGraphQL(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			values = append(values, reduceMQEResult(data[fmt.Sprintf("m%d", i)]))
+		}
+	}
+	return values, nil
+}
+
+// metricsDuration raises a duration with a step of seconds to minutes, the finest step metrics are stored in
+func metricsDuration(duration api.Duration) api.Duration {
+	if duration.Step != api.StepSecond {
+		return duration
+	}
+	// "2006-01-02 150405" becomes "2006-01-02 1504"
+	duration.Step = api.StepMinute
+	duration.Start = duration.Start[:max(len(duration.Start)-2, 0)]
+	duration.End = duration.End[:max(len(duration.End)-2, 0)]
+	return duration
+}
+
+// reduceMQEResult reduces an expression result to a single value.
+func reduceMQEResult(result api.ExpressionResult) mqeBatchValue {
+	if result.Error != nil && *result.Error != "" {
+		return mqeBatchValue{Error: *result.Error}
+	}
+	if len(result.Results) == 0 {
+		return mqeBatchValue{}
+	}
+
+	var sum float64
+	var count int
+	for _, v := range result.Results[0].Values {
+		if v == nil || v.Value == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(*v.Value, 64)
+		if err != nil {
+			continue
+		}
+		sum += value
+		count++
+	}
+	if count == 0 {
+		return mqeBatchValue{}
+	}
+	avg := sum / float64(count)
+	return mqeBatchValue{Value: &avg}
+}
+
 // listMQEMetrics lists available metrics
 func listMQEMetrics(ctx context.Context, req *MQEMetricsListRequest) (*mcp.CallToolResult, error) {
 	// GraphQL query for listing metrics
@@ -382,9 +339,9 @@ func listMQEMetrics(ctx context.Context, req *MQEMetricsListRequest) (*mcp.CallT
 		variables["regex"] = req.Regex
 	}
 
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
+	result, err := executeGraphQL(ctx, query, variables)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list metrics: %v", err)), nil
+		return oapErrorResult("list metrics", err), nil
 	}
 
 	jsonBytes, err := json.Marshal(result.Data)
@@ -430,9 +387,9 @@ func getMQEMetricsType(ctx context.Context, req *MQEMetricsTypeRequest) (*mcp.Ca
 		"name": req.MetricName,
 	}
 
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
+	result, err := executeGraphQL(ctx, query, variables)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get metric type: %v", err)), nil
+		return oapErrorResult("get metric type", err), nil
 	}
 
 	jsonBytes, err := json.Marshal(result.Data)
@@ -496,18 +453,18 @@ Examples:
 			"Examples: `service_sla`, `avg(service_cpm)`, `service_sla * 100`, `service_percentile{p='50,75,90,95,99'}`")),
 	mcp.WithString("service_name", mcp.Description("Service name for entity filtering")),
 	mcp.WithString("layer",
-		mcp.Description("Service layer for entity filtering. "+
-			"Examples: `GENERAL` (default), `MESH`, `K8S_SERVICE`, `DATABASE`, `VIRTUAL_DATABASE`. "+
-			"Defaults to GENERAL if not specified")),
+		mcp.Description("Layer in which service_name is looked up. "+
+			"Examples: `GENERAL`, `MESH`, `K8S_SERVICE`, `DATABASE`, `VIRTUAL_DATABASE`. "+
+			"Defaults to all layers")),
 	mcp.WithString("service_instance_name", mcp.Description("Service instance name for entity filtering")),
 	mcp.WithString("endpoint_name", mcp.Description("Endpoint name for entity filtering")),
 	mcp.WithString("process_name", mcp.Description("Process name for entity filtering")),
 	mcp.WithBoolean("normal",
 		mcp.Description("Whether the service is normal (has agent installed). "+
-			"If not specified, will be auto-detected based on service layer")),
+			"If not specified, will be auto-detected from the service")),
 	mcp.WithString("dest_service_name", mcp.Description("Destination service name for relation metrics")),
 	mcp.WithString("dest_layer",
-		mcp.Description("Destination service layer for relation metrics. "+
+		mcp.Description("Layer in which dest_service_name is looked up. "+
 			"Examples: `GENERAL`, `MESH`, `K8S_SERVICE`, `DATABASE`")),
 	mcp.WithString("dest_service_instance_name", mcp.Description("Destination service instance name for relation metrics")),
 	mcp.WithString("dest_endpoint_name", mcp.Description("Destination endpoint name for relation metrics")),
@@ -531,7 +488,7 @@ Examples:
 	mcp.WithBoolean("dump_db_rsp", mcp.Description("Dump database response for debugging")),
 )
 
-var MQEMetricsListTool = NewTool[MQEMetricsListRequest, *mcp.CallToolResult](
+var MQEMetricsListTool = NewFanOutTool[MQEMetricsListRequest, *mcp.CallToolResult](
 	"list_mqe_metrics",
 	`List available metrics in SkyWalking that can be used in MQE expressions.
 
@@ -0,0 +1,331 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/profiling"
+)
+
+// AddProfilingTools registers profiling-related tools with the MCP server
+func AddProfilingTools(srv *server.MCPServer) {
+	TraceProfilingTaskListTool.Register(srv)
+	CreateTraceProfilingTaskTool.Register(srv)
+	NetworkProfilingTaskListTool.Register(srv)
+}
+
+// Trace profiling defaults, matching swctl behavior
+const (
+	DefaultProfilingDuration         = 5  // minutes
+	DefaultProfilingDumpPeriod       = 10 // milliseconds
+	DefaultProfilingMaxSamplingCount = 5
+)
+
+// Error messages
+const (
+	ErrMissingServiceID       = "missing required parameter: service_id"
+	ErrMissingEndpointName    = "missing required parameter: endpoint_name"
+	ErrFailedToCreateTask     = "failed to create trace profiling task: %v"
+	ErrFailedToListTasks      = "failed to list trace profiling tasks: %v"
+	ErrFailedToListEBPFTasks  = "failed to list eBPF profiling tasks: %v"
+	ErrFailedToListSchedules  = "failed to list the schedules of eBPF profiling task %s: %v"
+	ErrProfilingTaskRejected  = "trace profiling task rejected by OAP: %s"
+	ErrNegativeProfilingParam = "duration, min_duration_threshold, dump_period and max_sampling_count cannot be negative"
+	ErrInvalidStartTime       = "invalid start_time '%s', use \"now\", a time relative to now like \"10m\", or a time like \"2025-01-01 12:00:00\""
+)
+
+// TraceProfilingTaskListRequest defines the parameters for listing trace profiling tasks
+type TraceProfilingTaskListRequest struct {
+	ServiceID    string `json:"service_id,omitempty"`
+	EndpointName string `json:"endpoint_name,omitempty"`
+}
+
+// CreateTraceProfilingTaskRequest defines the parameters for creating a trace profiling task
+type CreateTraceProfilingTaskRequest struct {
+	ServiceID            string `json:"service_id"`
+	EndpointName         string `json:"endpoint_name"`
+	StartTime            string `json:"start_time,omitempty"`
+	Duration             int    `json:"duration,omitempty"`
+	MinDurationThreshold int    `json:"min_duration_threshold,omitempty"`
+	DumpPeriod           int    `json:"dump_period,omitempty"`
+	MaxSamplingCount     int    `json:"max_sampling_count,omitempty"`
+}
+
+// NetworkProfilingTaskListRequest defines the parameters for listing eBPF network profiling tasks
+type NetworkProfilingTaskListRequest struct {
+	ServiceID         string `json:"service_id"`
+	ServiceInstanceID string `json:"service_instance_id,omitempty"`
+	WithSchedules     bool   `json:"with_schedules,omitempty"`
+}
+
+// NetworkProfilingTask is an eBPF network profiling task with the processes it has been scheduled on
+type NetworkProfilingTask struct {
+	*api.EBPFProfilingTask
+	Schedules []*api.EBPFProfilingSchedule `json:"schedules,omitempty"`
+}
+
+// validateCreateTraceProfilingTaskRequest validates and applies defaults to the creation request
+func validateCreateTraceProfilingTaskRequest(req *CreateTraceProfilingTaskRequest) error {
+	if req.ServiceID == "" {
+		return errors.New(ErrMissingServiceID)
+	}
+	if req.EndpointName == "" {
+		return errors.New(ErrMissingEndpointName)
+	}
+	if req.Duration < 0 || req.MinDurationThreshold < 0 || req.DumpPeriod < 0 || req.MaxSamplingCount < 0 {
+		return errors.New(ErrNegativeProfilingParam)
+	}
+	// a malformed start time must not start the task immediately
+	if _, ok := parseExactTime(req.StartTime); req.StartTime != "" && !ok {
+		return fmt.Errorf(ErrInvalidStartTime, req.StartTime)
+	}
+	if req.Duration == 0 {
+		req.Duration = DefaultProfilingDuration
+	}
+	if req.DumpPeriod == 0 {
+		req.DumpPeriod = DefaultProfilingDumpPeriod
+	}
+	if req.MaxSamplingCount == 0 {
+		req.MaxSamplingCount = DefaultProfilingMaxSamplingCount
+	}
+	return nil
+}
+
+// listTraceProfilingTasks lists the trace profiling tasks of a service or endpoint
+func listTraceProfilingTasks(ctx context.Context, req *TraceProfilingTaskListRequest) (*mcp.CallToolResult, error) {
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	req.ServiceID = serviceID
+	if req.ServiceID != "" && req.EndpointName != "" {
+		if _, err := ResolveEndpointID(ctx, req.ServiceID, req.EndpointName); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	tasks, err := profiling.GetTraceProfilingTaskList(ctx, req.ServiceID, req.EndpointName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListTasks, err)), nil
+	}
+
+	jsonBytes, err := json.Marshal(tasks)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrMarshalFailed, err)), nil
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// listNetworkProfilingTasks lists the eBPF network profiling tasks of a service, newest first.
+// OAP lists the tasks per trigger type, so both fixed time and continuous profiling tasks are queried.
+func listNetworkProfilingTasks(ctx context.Context, req *NetworkProfilingTaskListRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceID == "" {
+		return mcp.NewToolResultError(ErrMissingServiceID), nil
+	}
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	instanceID := ""
+	if req.ServiceInstanceID != "" {
+		if instanceID, err = ResolveInstanceID(ctx, serviceID, req.ServiceInstanceID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	tasks := make([]*NetworkProfilingTask, 0)
+	for _, triggerType := range api.AllEBPFProfilingTriggerType {
+		list, err := profiling.QueryEBPFProfilingTaskList(ctx, serviceID, triggerType)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListEBPFTasks, err)), nil
+		}
+		for _, task := range list {
+			if task.TargetType != api.EBPFProfilingTargetTypeNetwork {
+				continue
+			}
+			if instanceID != "" && (task.ServiceInstanceID == nil || *task.ServiceInstanceID != instanceID) {
+				continue
+			}
+			tasks = append(tasks, &NetworkProfilingTask{EBPFProfilingTask: task})
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].CreateTime > tasks[j].CreateTime })
+
+	if req.WithSchedules {
+		for _, task := range tasks {
+			schedules, err := profiling.QueryEBPFProfilingScheduleList(ctx, task.TaskID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToListSchedules, task.TaskID, err)), nil
+			}
+			task.Schedules = schedules
+		}
+	}
+	return toolResultJSON(tasks), nil
+}
+
+// createTraceProfilingTask creates a new trace profiling task in OAP
+func createTraceProfilingTask(ctx context.Context, req *CreateTraceProfilingTaskRequest) (*mcp.CallToolResult, error) {
+	if err := validateCreateTraceProfilingTaskRequest(req); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	req.ServiceID = serviceID
+	if _, err := ResolveEndpointID(ctx, req.ServiceID, req.EndpointName); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	condition := &api.ProfileTaskCreationRequest{
+		ServiceID:            req.ServiceID,
+		EndpointName:         req.EndpointName,
+		Duration:             req.Duration,
+		MinDurationThreshold: req.MinDurationThreshold,
+		DumpPeriod:           req.DumpPeriod,
+		MaxSamplingCount:     req.MaxSamplingCount,
+	}
+	// Without a start time OAP starts the task immediately
+	if req.StartTime != "" {
+		startTime, _ := parseExactTime(req.StartTime)
+		startTimeMillis := startTime.UnixMilli()
+		condition.StartTime = &startTimeMillis
+	}
+
+	result, err := profiling.CreateTraceTask(ctx, condition)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToCreateTask, err)), nil
+	}
+	if result.ErrorReason != nil && *result.ErrorReason != "" {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrProfilingTaskRejected, *result.ErrorReason)), nil
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrMarshalFailed, err)), nil
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// TraceProfilingTaskListTool is a tool for listing trace profiling tasks
+var TraceProfilingTaskListTool = NewTool[TraceProfilingTaskListRequest, *mcp.CallToolResult](
+	"list_trace_profiling_tasks",
+	`List trace profiling tasks created in SkyWalking OAP.
+
+Trace profiling samples the thread stacks of slow requests on a specific endpoint,
+which helps to find the code that dominates the latency of a request.
+
+Workflow:
+1. Use this tool to check whether a profiling task already exists for an endpoint
+2. Inspect the task logs to see which instances have received and executed the task
+
+Examples:
+- {"service_id": "c2VydmljZQ==.1"}: All profiling tasks of a service
+- {"service_id": "c2VydmljZQ==.1", "endpoint_name": "/api/orders"}: Profiling tasks of a specific endpoint`,
+	listTraceProfilingTasks,
+	mcp.WithTitleAnnotation("List trace profiling tasks"),
+	mcp.WithString("service_id",
+		mcp.Description("Service ID or name to list profiling tasks for."),
+	),
+	mcp.WithString("endpoint_name",
+		mcp.Description("Endpoint name to list profiling tasks for."),
+	),
+)
+
+// NetworkProfilingTaskListTool is a tool for listing eBPF network profiling tasks
+var NetworkProfilingTaskListTool = NewTool[NetworkProfilingTaskListRequest, *mcp.CallToolResult](
+	"list_network_profiling_tasks",
+	`List the eBPF network profiling tasks of a service in SkyWalking OAP.
+
+Network profiling is done by SkyWalking Rover on Kubernetes nodes. It captures the traffic between
+the processes of a pod without any agent, which builds the process topology and the Process and
+ProcessRelation metrics. This tool is read-only, tasks are created in the SkyWalking UI or with swctl.
+
+Workflow:
+1. Use this tool to check whether the traffic of an instance is being profiled
+2. Use with_schedules to see the processes the tasks have run on and when
+3. Use get_process_topology and list_processes to analyze the profiled instance
+
+Examples:
+- {"service_id": "agent::order-service"}: Network profiling tasks of a service
+- {"service_id": "agent::order-service", "service_instance_id": "order-7d9f-x2v4", "with_schedules": true}:
+  Network profiling tasks of a pod with their schedules`,
+	listNetworkProfilingTasks,
+	mcp.WithTitleAnnotation("List network profiling tasks"),
+	mcp.WithString("service_id", mcp.Required(),
+		mcp.Description("Service ID or name to list network profiling tasks for."),
+	),
+	mcp.WithString("service_instance_id",
+		mcp.Description("Service instance ID or name, only the tasks of the instance."),
+	),
+	mcp.WithBoolean("with_schedules",
+		mcp.Description("Include the schedules of every task, with the profiled processes and time ranges. Default is false."),
+	),
+)
+
+// CreateTraceProfilingTaskTool is a tool for creating trace profiling tasks
+var CreateTraceProfilingTaskTool = NewMutatingTool[CreateTraceProfilingTaskRequest, *mcp.CallToolResult](
+	"create_trace_profiling_task",
+	`Create a trace profiling task in SkyWalking OAP.
+
+The agents of the service sample the thread stacks of requests to the endpoint that are slower
+than min_duration_threshold, during the monitoring duration. The sampled segments can be analyzed
+afterwards to find the code that dominates the latency.
+
+Important Notes:
+- This tool changes OAP state and is not available when the server runs in read-only mode
+- Only one task per endpoint can be active at the same time
+- Profiling adds overhead to the monitored instances, keep the duration short
+
+Examples:
+- {"service_id": "c2VydmljZQ==.1", "endpoint_name": "/api/orders"}: Profile an endpoint for 5 minutes starting now
+- {"service_id": "c2VydmljZQ==.1", "endpoint_name": "/api/orders", "min_duration_threshold": 500, "duration": 10}:
+  Profile requests slower than 500ms for 10 minutes`,
+	createTraceProfilingTask,
+	mcp.WithTitleAnnotation("Create a trace profiling task"),
+	mcp.WithString("service_id", mcp.Required(),
+		mcp.Description("Service ID or name of the endpoint to profile."),
+	),
+	mcp.WithString("endpoint_name", mcp.Required(),
+		mcp.Description("Name of the endpoint to profile, e.g. /api/orders."),
+	),
+	mcp.WithString("start_time",
+		mcp.Description("Time to start the task. Examples: \"2025-01-01 12:00:00\", \"10m\" (10 minutes later). Default is now."),
+	),
+	mcp.WithNumber("duration",
+		mcp.Description("Monitoring duration in minutes. Default is 5."),
+	),
+	mcp.WithNumber("min_duration_threshold",
+		mcp.Description("Only requests slower than this threshold in milliseconds are profiled. Default is 0."),
+	),
+	mcp.WithNumber("dump_period",
+		mcp.Description("Interval between thread stack dumps in milliseconds. Default is 10."),
+	),
+	mcp.WithNumber("max_sampling_count",
+		mcp.Description("Maximum number of requests sampled per instance. Default is 5."),
+	),
+)
@@ -0,0 +1,333 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+
+	"github.com/apache/skywalking-mcp/internal/oap"
+)
+
+// Resolver settings
+const (
+	// resolverCacheTTL bounds how long listings used to resolve names are reused
+	resolverCacheTTL = time.Minute
+	// resolverLoadTimeout bounds a shared listing load, which outlives the cancellation of the requests waiting for it
+	resolverLoadTimeout = 30 * time.Second
+	// resolverInstanceWindow is the time range in which instances are looked up by name
+	resolverInstanceWindow = 24 * time.Hour
+	// resolverEndpointLimit is the number of endpoints fetched by the first lookup
+	resolverEndpointLimit = 100
+	// resolverMaxEndpointLimit is the largest number of endpoints fetched by a lookup
+	resolverMaxEndpointLimit = 10000
+	// maxSuggestions is the number of candidates reported when a name does not match
+	maxSuggestions = 5
+)
+
+// Entity kinds
+const (
+	entityService  = "service"
+	entityInstance = "instance"
+	entityEndpoint = "endpoint"
+)
+
+// listingTools are the tools listing the entities of a kind
+var listingTools = map[string]string{
+	entityService:  "list_services",
+	entityInstance: "list_instances",
+	entityEndpoint: "search_endpoints",
+}
+
+// NotFoundError is returned when no entity has exactly the given name.
+type NotFoundError struct {
+	Kind        string
+	Name        string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("%s %q not found, use %s to find the available names", e.Kind, e.Name, listingTools[e.Kind])
+	}
+	return fmt.Sprintf("%s %q not found, did you mean: %s", e.Kind, e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// EncodeServiceID encodes a service name into its OAP ID, the reverse of ParseServiceID.
+func EncodeServiceID(name string, normal bool) string {
+	flag := "0"
+	if normal {
+		flag = "1"
+	}
+	return base64.StdEncoding.EncodeToString([]byte(name)) + "." + flag
+}
+
+// EncodeEntityID encodes the name of an instance or endpoint of a service into its OAP ID.
+func EncodeEntityID(serviceID, name string) string {
+	return serviceID + "_" + base64.StdEncoding.EncodeToString([]byte(name))
+}
+
+// isServiceID reports whether s is a service ID rather than a service name.
+// IDs are recognized by decoding and encoding them again, so names that merely contain a dot are kept.
+func isServiceID(s string) bool {
+	name, normal, err := ParseServiceID(s)
+	return err == nil && name != "" && EncodeServiceID(name, normal) == s
+}
+
+// isEntityID reports whether s is an instance or endpoint ID rather than a name.
+func isEntityID(s string) bool {
+	serviceID, encoded, ok := strings.Cut(s, "_")
+	if !ok || !isServiceID(serviceID) {
+		return false
+	}
+	name, err := base64.StdEncoding.DecodeString(encoded)
+	return err == nil && len(name) > 0 && EncodeEntityID(serviceID, string(name)) == s
+}
+
+// ttlCache caches values per key for a fixed time.
+// Concurrent lookups of a key share a single load.
+type ttlCache[V any] struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	err     error
+	expires time.Time
+	loaded  chan struct{}
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, entries: make(map[string]*ttlEntry[V])}
+}
+
+// get returns the cached value of the key, or loads and caches it.
+// The load is detached from the cancellation of ctx, since concurrent callers of the key wait for it,
+// while each caller stops waiting when its own ctx is done. Failed loads are not cached.
+func (c *ttlCache[V]) get(ctx context.Context, key string, load func(ctx context.Context) (V, error)) (V, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || (!entry.expires.IsZero() && !now.Before(entry.expires)) {
+		for k, e := range c.entries {
+			if !e.expires.IsZero() && !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		entry = &ttlEntry[V]{loaded: make(chan struct{})}
+		c.entries[key] = entry
+		go c.load(context.WithoutCancel(ctx), key, entry, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.loaded:
+		return entry.value, entry.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load fills in the entry of the key, dropping it when the load fails.
+func (c *ttlCache[V]) load(ctx context.Context, key string, entry *ttlEntry[V], load func(ctx context.Context) (V, error)) {
+	ctx, cancel := context.WithTimeout(ctx, resolverLoadTimeout)
+	defer cancel()
+	value, err := load(ctx)
+
+	c.mu.Lock()
+	entry.value, entry.err = value, err
+	if err != nil {
+		delete(c.entries, key)
+	} else {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(entry.loaded)
+}
+
+// Listings are cached per OAP and credentials, since each request may target another OAP
+// with credentials that see other entities
+var (
+	serviceCache  = newTTLCache[[]api.Service](resolverCacheTTL)
+	instanceCache = newTTLCache[[]api.ServiceInstance](resolverCacheTTL)
+	endpointCache = newTTLCache[[]api.Endpoint](resolverCacheTTL)
+)
+
+// cacheKey builds the cache key of a listing of the OAP and credentials of the current request.
+func cacheKey(ctx context.Context, parts ...string) string {
+	return strings.Join(append([]string{oap.EndpointFromContext(ctx), oap.AuthorizationFromContext(ctx)}, parts...), "\x00")
+}
+
+// ResolveServiceID resolves a service name or ID into the service ID, looking through all layers.
+// An empty value resolves to an empty ID.
+func ResolveServiceID(ctx context.Context, nameOrID string) (string, error) {
+	return resolveServiceInLayer(ctx, nameOrID, "")
+}
+
+// resolveServiceInLayer resolves a service name or ID into the service ID,
+// looking only through the services of the layer unless it is empty.
+func resolveServiceInLayer(ctx context.Context, nameOrID, layer string) (string, error) {
+	if nameOrID == "" || isServiceID(nameOrID) {
+		return nameOrID, nil
+	}
+
+	services, err := serviceCache.get(ctx, cacheKey(ctx), func(ctx context.Context) ([]api.Service, error) {
+		return listAllServices(ctx, "")
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list services to resolve %q: %w", nameOrID, err)
+	}
+
+	names := make([]string, 0, len(services))
+	for i := range services {
+		inLayer := layer == "" || slices.ContainsFunc(services[i].Layers, func(l string) bool {
+			return strings.EqualFold(l, layer)
+		})
+		if services[i].Name == nameOrID {
+			if !inLayer {
+				return "", fmt.Errorf("service %q is not in layer %s but in %s", nameOrID, layer, strings.Join(services[i].Layers, ", "))
+			}
+			return services[i].ID, nil
+		}
+		if inLayer {
+			names = append(names, services[i].Name)
+		}
+	}
+	return "", &NotFoundError{Kind: entityService, Name: nameOrID, Suggestions: suggest(nameOrID, names)}
+}
+
+// ResolveInstanceID resolves an instance name or ID into the instance ID.
+// Names are looked up among the instances of the service that were alive in the past day.
+func ResolveInstanceID(ctx context.Context, serviceID, nameOrID string) (string, error) {
+	if nameOrID == "" || isEntityID(nameOrID) {
+		return nameOrID, nil
+	}
+	if serviceID == "" {
+		return "", fmt.Errorf("service_id is required to resolve instance name %q", nameOrID)
+	}
+
+	instances, err := instanceCache.get(ctx, cacheKey(ctx, serviceID), func(ctx context.Context) ([]api.ServiceInstance, error) {
+		duration := ParseDuration("-"+resolverInstanceWindow.String(), false)
+		return metadata.Instances(ctx, serviceID, duration)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list instances to resolve %q: %w", nameOrID, err)
+	}
+
+	names := make([]string, len(instances))
+	for i := range instances {
+		if instances[i].Name == nameOrID {
+			return instances[i].ID, nil
+		}
+		names[i] = instances[i].Name
+	}
+	return "", &NotFoundError{Kind: entityInstance, Name: nameOrID, Suggestions: suggest(nameOrID, names)}
+}
+
+// ResolveEndpointID resolves an endpoint name or ID into the endpoint ID.
+// Endpoint IDs derive from the service ID and the name, so the lookup only catches typos: a name is rejected
+// when no endpoint contains it, or when all endpoints containing it were listed without an exact match.
+// Otherwise, e.g. when too many endpoints contain the name or the lookup fails, the name is encoded as is.
+func ResolveEndpointID(ctx context.Context, serviceID, nameOrID string) (string, error) {
+	if nameOrID == "" || isEntityID(nameOrID) {
+		return nameOrID, nil
+	}
+	if serviceID == "" {
+		return "", fmt.Errorf("service_id is required to resolve endpoint name %q", nameOrID)
+	}
+
+	// findEndpoint has no offset, so the endpoints containing the name are listed again with a larger limit
+	for limit := resolverEndpointLimit; limit <= resolverMaxEndpointLimit; limit *= 10 {
+		endpoints, err := cachedEndpoints(ctx, serviceID, nameOrID, limit)
+		if err != nil {
+			break
+		}
+		for i := range endpoints {
+			if endpoints[i].Name == nameOrID {
+				return endpoints[i].ID, nil
+			}
+		}
+		if len(endpoints) < limit {
+			return "", endpointNotFound(ctx, serviceID, nameOrID, endpoints)
+		}
+	}
+	return EncodeEntityID(serviceID, nameOrID), nil
+}
+
+// cachedEndpoints lists up to limit endpoints of the service containing the keyword.
+func cachedEndpoints(ctx context.Context, serviceID, keyword string, limit int) ([]api.Endpoint, error) {
+	key := cacheKey(ctx, serviceID, keyword, strconv.Itoa(limit))
+	return endpointCache.get(ctx, key, func(ctx context.Context) ([]api.Endpoint, error) {
+		return metadata.SearchEndpoints(ctx, serviceID, keyword, limit, nil)
+	})
+}
+
+// endpointNotFound reports the endpoints containing the name and the first endpoints of the service as candidates.
+func endpointNotFound(ctx context.Context, serviceID, name string, candidates []api.Endpoint) error {
+	// the first endpoints only add suggestions, failing to list them does not matter
+	if endpoints, err := cachedEndpoints(ctx, serviceID, "", resolverEndpointLimit); err == nil {
+		candidates = append(candidates, endpoints...)
+	}
+	names := make([]string, len(candidates))
+	for i := range candidates {
+		names[i] = candidates[i].Name
+	}
+	return &NotFoundError{Kind: entityEndpoint, Name: name, Suggestions: suggest(name, names)}
+}
+
+// resolveServiceName resolves a service name or ID into the service name and normal flag,
+// which identify services in entity based queries. The layer restricts the lookup unless it is empty.
+func resolveServiceName(ctx context.Context, nameOrID, layer string) (name string, normal bool, err error) {
+	serviceID, err := resolveServiceInLayer(ctx, nameOrID, layer)
+	if err != nil {
+		return "", false, err
+	}
+	return ParseServiceID(serviceID)
+}
+
+// checkEndpointName verifies that the service has an endpoint with the name, to report close names on typos.
+func checkEndpointName(ctx context.Context, serviceName string, normal bool, endpointName string) error {
+	_, err := ResolveEndpointID(ctx, EncodeServiceID(serviceName, normal), endpointName)
+	return err
+}
+
+// resolveEntityIDs resolves the service, instance and endpoint filters of a query in place.
+func resolveEntityIDs(ctx context.Context, serviceID, instanceID, endpointID *string) error {
+	var err error
+	if *serviceID, err = ResolveServiceID(ctx, *serviceID); err != nil {
+		return err
+	}
+	if *instanceID, err = ResolveInstanceID(ctx, *serviceID, *instanceID); err != nil {
+		return err
+	}
+	*endpointID, err = ResolveEndpointID(ctx, *serviceID, *endpointID)
+	return err
+}
@@ -26,11 +26,23 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// readOnly indicates whether tools that mutate OAP state should be left unregistered.
+var readOnly bool
+
+// SetReadOnly controls whether mutating tools are skipped by Register.
+func SetReadOnly(enabled bool) {
+	readOnly = enabled
+}
+
 type Tool[T any, R any] struct {
 	Name        string
 	Description string
 	Handler     func(ctx context.Context, args *T) (R, error)
 	Options     []mcp.ToolOption
+	// Mutating marks tools that change OAP state, e.g. creating profiling tasks.
+	Mutating bool
+	// FanOut marks tools that can query all clusters concurrently, see AllClusters.
+	FanOut bool
 }
 
 func NewTool[T any, R any](
@@ -46,12 +58,57 @@ func NewTool[T any, R any](
 	}
 }
 
+// NewMutatingTool creates a tool that changes OAP state.
+// Such tools are not registered when the server runs in read-only mode.
+func NewMutatingTool[T any, R any](
+	name, desc string,
+	handler func(ctx context.Context, args *T) (R, error),
+	options ...mcp.ToolOption,
+) *Tool[T, R] {
+	tool := NewTool(name, desc, handler, options...)
+	tool.Mutating = true
+	return tool
+}
+
+// NewFanOutTool creates a tool that can query all configured clusters concurrently.
+func NewFanOutTool[T any, R any](
+	name, desc string,
+	handler func(ctx context.Context, args *T) (R, error),
+	options ...mcp.ToolOption,
+) *Tool[T, R] {
+	tool := NewTool(name, desc, handler, options...)
+	tool.FanOut = true
+	return tool
+}
+
 // Register registers the tool with the given MCP server.
+// Mutating tools are skipped when the server runs in read-only mode.
+// If clusters are configured, the tool gets a cluster argument to select one.
 func (t *Tool[T, R]) Register(server *server.MCPServer) {
-	tool, handler, err := ConvertTool[T, R](t.Name, t.Description, t.Handler, t.Options...)
+	if t.Mutating && readOnly {
+		return
+	}
+
+	options := []mcp.ToolOption{
+		mcp.WithReadOnlyHintAnnotation(!t.Mutating),
+		mcp.WithDestructiveHintAnnotation(false),
+	}
+	if t.Mutating {
+		// every call creates a new entity in OAP
+		options = append(options, mcp.WithIdempotentHintAnnotation(false))
+	}
+	options = append(options, t.Options...)
+	if len(clusterNames) > 0 {
+		options = append(options, clusterOption(t.FanOut && !t.Mutating))
+	}
+
+	tool, handler, err := ConvertTool[T, R](t.Name, t.Description, t.Handler, options...)
 	if err != nil {
 		panic(err)
 	}
+	if len(clusterNames) > 0 {
+		handler = clusterHandler(handler, t.FanOut && !t.Mutating)
+	}
 
 	server.AddTool(tool, handler)
 }
@@ -18,31 +18,64 @@
 package tools
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/spf13/viper"
 	api "skywalking.apache.org/repo/goapi/query"
 )
 
 // AddTopologyTools registers topology-related tools with the MCP server
 func AddTopologyTools(srv *server.MCPServer) {
 	ServiceTopologyTool.Register(srv)
+	GlobalTopologyTool.Register(srv)
 	InstanceTopologyTool.Register(srv)
+	ProcessTopologyTool.Register(srv)
 	EndpointTopologyTool.Register(srv)
+	ImpactTool.Register(srv)
 }
 
+// Topology depth limits, in hops from the queried service
+const (
+	DefaultTopologyDepth = 1
+	MaxTopologyDepth     = 5
+)
+
+// Global topology defaults
+const (
+	DefaultGlobalTopologyMaxCalls = 50
+)
+
+// MaxTopologyMetrics bounds the number of expressions evaluated per node and per call
+const MaxTopologyMetrics = 10
+
+// Error messages
+const (
+	ErrInvalidMaxCalls             = "max_calls must be a positive integer"
+	ErrTooManyTopologyMetrics      = "at most %d expressions are allowed in node_metrics and in call_metrics"
+	ErrEmptyTopologyMetric         = "node_metrics and call_metrics cannot contain empty expressions"
+	ErrInvalidTopologyDepth        = "depth must be between 1 and %d"
+	ErrMissingInstanceTopologyPair = "both source_service and dest_service must be provided"
+	ErrMissingProcessTopologyScope = "missing required parameter: service_instance_id"
+)
+
 // TopologyRequest defines the parameters for the topology query tool
 type TopologyRequest struct {
-	ServiceID         string `json:"service_id,omitempty"`
-	ServiceName       string `json:"service_name,omitempty"`
-	Duration          string `json:"duration,omitempty"`
-	Depth             int    `json:"depth,omitempty"`
-	MetricNames       string `json:"metric_names,omitempty"`
+	ServiceID   string `json:"service_id,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	Duration    string `json:"duration,omitempty"`
+}
+
+// TopologyMetricsRequest defines the MQE expressions evaluated for every node and call of a topology
+type TopologyMetricsRequest struct {
+	NodeMetrics []string `json:"node_metrics,omitempty"`
+	CallMetrics []string `json:"call_metrics,omitempty"`
 }
 
 // ServiceTopologyRequest defines the parameters for service topology
@@ -51,13 +84,89 @@ type ServiceTopologyRequest struct {
 	ServiceName string `json:"service_name,omitempty"`
 	Duration    string `json:"duration,omitempty"`
 	Depth       int    `json:"depth,omitempty"`
+	Format      string `json:"format,omitempty"`
+	TopologyMetricsRequest
 }
 
 // InstanceTopologyRequest defines the parameters for instance topology
 type InstanceTopologyRequest struct {
-	ServiceID   string `json:"service_id,omitempty"`
-	ServiceName string `json:"service_name,omitempty"`
-	Duration    string `json:"duration,omitempty"`
+	SourceService string `json:"source_service"`
+	DestService   string `json:"dest_service"`
+	Duration      string `json:"duration,omitempty"`
+	Format        string `json:"format,omitempty"`
+	TopologyMetricsRequest
+}
+
+// ProcessTopologyRequest defines the parameters for the process topology of a service instance
+type ProcessTopologyRequest struct {
+	ServiceID         string `json:"service_id,omitempty"`
+	ServiceInstanceID string `json:"service_instance_id"`
+	Duration          string `json:"duration,omitempty"`
+	Format            string `json:"format,omitempty"`
+	TopologyMetricsRequest
+}
+
+// GlobalTopologyRequest defines the parameters for the global topology
+type GlobalTopologyRequest struct {
+	Layer          string `json:"layer,omitempty"`
+	Duration       string `json:"duration,omitempty"`
+	CollapseGroups bool   `json:"collapse_groups,omitempty"`
+	MaxCalls       int    `json:"max_calls,omitempty"`
+	Format         string `json:"format,omitempty"`
+	TopologyMetricsRequest
+}
+
+// TopologyNode is a node of a topology, a service, a service instance, an endpoint or a process
+type TopologyNode struct {
+	ID                  string   `json:"id"`
+	Name                string   `json:"name"`
+	Type                string   `json:"type,omitempty"`
+	IsReal              bool     `json:"is_real"`
+	Layers              []string `json:"layers,omitempty"`
+	ServiceID           string   `json:"service_id,omitempty"`
+	ServiceName         string   `json:"service_name,omitempty"`
+	ServiceInstanceID   string   `json:"service_instance_id,omitempty"`
+	ServiceInstanceName string   `json:"service_instance_name,omitempty"`
+	// Hops is the distance from the queried service in expanded topologies
+	Hops *int `json:"hops,omitempty"`
+	// Members are the services of a node collapsed by service group
+	Members []string `json:"members,omitempty"`
+	// InternalCalls is the number of calls between the members of a collapsed node
+	InternalCalls int `json:"internal_calls,omitempty"`
+	// Metrics are the values of the node_metrics expressions, null when OAP has no data
+	Metrics map[string]*float64 `json:"metrics,omitempty"`
+}
+
+// TopologyCall is a call edge between two nodes of a topology
+type TopologyCall struct {
+	ID               string   `json:"id"`
+	Source           string   `json:"source"`
+	Target           string   `json:"target"`
+	SourceComponents []string `json:"source_components,omitempty"`
+	TargetComponents []string `json:"target_components,omitempty"`
+	DetectPoints     []string `json:"detect_points,omitempty"`
+	// CPM is the calls per minute, averaged over the duration
+	CPM *float64 `json:"cpm,omitempty"`
+	// Merged is the number of calls merged into a call between collapsed nodes
+	Merged int `json:"merged,omitempty"`
+	// Metrics are the values of the call_metrics expressions, null when OAP has no data
+	Metrics map[string]*float64 `json:"metrics,omitempty"`
+}
+
+// TopologySummary describes the calls left out of a capped topology
+type TopologySummary struct {
+	OmittedCalls int     `json:"omitted_calls"`
+	OmittedNodes int     `json:"omitted_nodes"`
+	OmittedCPM   float64 `json:"omitted_cpm"`
+}
+
+// Topology is a graph of nodes and the calls between them
+type Topology struct {
+	Nodes   []*TopologyNode  `json:"nodes"`
+	Calls   []*TopologyCall  `json:"calls"`
+	Summary *TopologySummary `json:"summary,omitempty"`
+	// MetricErrors are the errors reported by OAP per expression
+	MetricErrors map[string]string `json:"metric_errors,omitempty"`
 }
 
 // EndpointTopologyRequest defines the parameters for endpoint topology
@@ -65,6 +174,7 @@ type EndpointTopologyRequest struct {
 	ServiceID   string `json:"service_id,omitempty"`
 	ServiceName string `json:"service_name,omitempty"`
 	Duration    string `json:"duration,omitempty"`
+	Format      string `json:"format,omitempty"`
 }
 
 // validateTopologyRequest validates topology request parameters
@@ -76,158 +186,808 @@ func validateTopologyRequest(req *TopologyRequest) error {
 	return nil
 }
 
+// servicesTopologyQuery queries the calls from and to the given services
+const servicesTopologyQuery = `
+	query getServicesTopology($serviceIds: [ID!]!, $duration: Duration!) {
+		topology: getServicesTopology(serviceIds: $serviceIds, duration: $duration) {
+			nodes {
+				id
+				name
+				type
+				isReal
+				layers
+			}
+			calls {
+				id
+				source
+				target
+				sourceComponents
+				targetComponents
+				detectPoints
+			}
+		}
+	}
+`
+
+// globalTopologyQuery queries the calls between all services, or between the services of a layer
+const globalTopologyQuery = `
+	query getGlobalTopology($duration: Duration!, $layer: String) {
+		topology: getGlobalTopology(duration: $duration, layer: $layer) {
+			nodes {
+				id
+				name
+				type
+				isReal
+				layers
+			}
+			calls {
+				id
+				source
+				target
+				sourceComponents
+				targetComponents
+				detectPoints
+			}
+		}
+	}
+`
+
+// instanceTopologyQuery queries the calls between the instances of a client and a server service
+const instanceTopologyQuery = `
+	query getServiceInstanceTopology($clientServiceId: ID!, $serverServiceId: ID!, $duration: Duration!) {
+		topology: getServiceInstanceTopology(clientServiceId: $clientServiceId, serverServiceId: $serverServiceId, duration: $duration) {
+			nodes {
+				id
+				name
+				type
+				isReal
+				serviceId
+				serviceName
+			}
+			calls {
+				id
+				source
+				target
+				sourceComponents
+				targetComponents
+				detectPoints
+			}
+		}
+	}
+`
+
+// endpointTopologyQuery queries the calls between the endpoints of a service and their dependencies
+const endpointTopologyQuery = `
+	query getEndpointTopology($serviceId: ID!, $duration: Duration!) {
+		topology: getEndpointTopology(serviceId: $serviceId, duration: $duration) {
+			nodes {
+				id
+				name
+				type
+				isReal
+				serviceId
+				serviceName
+			}
+			calls {
+				id
+				source
+				target
+				detectPoints
+			}
+		}
+	}
+`
+
+// processTopologyQuery queries the calls between the processes of a service instance
+const processTopologyQuery = `
+	query getProcessTopology($serviceInstanceId: ID!, $duration: Duration!) {
+		topology: getProcessTopology(serviceInstanceId: $serviceInstanceId, duration: $duration) {
+			nodes {
+				id
+				name
+				isReal
+				serviceId
+				serviceName
+				serviceInstanceId
+				serviceInstanceName
+			}
+			calls {
+				id
+				source
+				target
+				sourceComponents
+				targetComponents
+				detectPoints
+			}
+		}
+	}
+`
+
+// topologyDuration parses the duration of a topology query, defaulting to the configured duration
+func topologyDuration(duration string) api.Duration {
+	if duration != "" {
+		return ParseDuration(duration, false)
+	}
+	return BuildDuration("", "", "", false, configuredDurationMinutes())
+}
+
 // queryServiceTopology queries service topology from SkyWalking OAP
 func queryServiceTopology(ctx context.Context, req *ServiceTopologyRequest) (*mcp.CallToolResult, error) {
-	topologyReq := &TopologyRequest{
-		ServiceID:   req.ServiceID,
-		ServiceName: req.ServiceName,
-		Duration:    req.Duration,
-		Depth:       req.Depth,
+	if err := validateTopologyRequest(&TopologyRequest{ServiceID: req.ServiceID, ServiceName: req.ServiceName}); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if req.Depth == 0 {
+		req.Depth = DefaultTopologyDepth
+	}
+	if req.Depth < 1 || req.Depth > MaxTopologyDepth {
+		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidTopologyDepth, MaxTopologyDepth)), nil
+	}
+	if err := validateTopologyFormat(req.Format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := req.validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	serviceID, err := ResolveServiceID(ctx, cmp.Or(req.ServiceID, req.ServiceName))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := topologyDuration(req.Duration)
+	topology, err := expandServiceTopology(ctx, serviceID, req.Depth, duration)
+	if err != nil {
+		return oapErrorResult("query Service topology", err), nil
+	}
+	if err := annotateTopology(ctx, topology, &req.TopologyMetricsRequest, duration); err != nil {
+		return oapErrorResult("query the metrics of the Service topology", err), nil
 	}
-	return queryTopology(ctx, topologyReq, "Service")
+	return topologyResult(topology, req.Format), nil
 }
 
-// queryInstanceTopology queries instance topology from SkyWalking OAP
+// expandServiceTopology expands the topology breadth-first from a service, up to depth hops.
+// Every hop is a single query for the calls of all services discovered by the previous hop.
+func expandServiceTopology(ctx context.Context, serviceID string, depth int, duration api.Duration) (*Topology, error) {
+	nodes := make(map[string]*TopologyNode)
+	calls := make(map[string]*TopologyCall)
+	hops := map[string]int{serviceID: 0}
+
+	frontier := []string{serviceID}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		var data struct {
+			Topology api.Topology `json:"topology"`
+		}
+		variables := map[string]interface{}{"serviceIds": frontier, "duration": duration}
+		if err := queryGraphQL(ctx, servicesTopologyQuery, variables, &data); err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, node := range data.Topology.Nodes {
+			if _, ok := nodes[node.ID]; !ok {
+				nodes[node.ID] = serviceNode(node)
+			}
+			if _, ok := hops[node.ID]; !ok {
+				hops[node.ID] = hop
+				frontier = append(frontier, node.ID)
+			}
+		}
+		for _, call := range data.Topology.Calls {
+			calls[call.ID] = topologyCall(call)
+		}
+	}
+
+	for id, node := range nodes {
+		h := hops[id]
+		node.Hops = &h
+	}
+	return newTopology(nodes, calls), nil
+}
+
+// queryInstanceTopology queries the instance topology between two services from SkyWalking OAP
 func queryInstanceTopology(ctx context.Context, req *InstanceTopologyRequest) (*mcp.CallToolResult, error) {
-	topologyReq := &TopologyRequest{
-		ServiceID:   req.ServiceID,
-		ServiceName: req.ServiceName,
-		Duration:    req.Duration,
+	if req.SourceService == "" || req.DestService == "" {
+		return mcp.NewToolResultError(ErrMissingInstanceTopologyPair), nil
+	}
+	if err := validateTopologyFormat(req.Format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	return queryTopology(ctx, topologyReq, "Instance")
+	if err := req.validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	clientServiceID, err := ResolveServiceID(ctx, req.SourceService)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	serverServiceID, err := ResolveServiceID(ctx, req.DestService)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := topologyDuration(req.Duration)
+	var data struct {
+		Topology api.ServiceInstanceTopology `json:"topology"`
+	}
+	variables := map[string]interface{}{
+		"clientServiceId": clientServiceID,
+		"serverServiceId": serverServiceID,
+		"duration":        duration,
+	}
+	if err := queryGraphQL(ctx, instanceTopologyQuery, variables, &data); err != nil {
+		return oapErrorResult("query Instance topology", err), nil
+	}
+
+	nodes := make(map[string]*TopologyNode, len(data.Topology.Nodes))
+	for _, node := range data.Topology.Nodes {
+		nodes[node.ID] = instanceNode(node)
+	}
+	calls := make(map[string]*TopologyCall, len(data.Topology.Calls))
+	for _, call := range data.Topology.Calls {
+		calls[call.ID] = topologyCall(call)
+	}
+	topology := newTopology(nodes, calls)
+	if err := annotateTopology(ctx, topology, &req.TopologyMetricsRequest, duration); err != nil {
+		return oapErrorResult("query the metrics of the Instance topology", err), nil
+	}
+	return topologyResult(topology, req.Format), nil
 }
 
-// queryEndpointTopology queries endpoint topology from SkyWalking OAP
-func queryEndpointTopology(ctx context.Context, req *EndpointTopologyRequest) (*mcp.CallToolResult, error) {
-	topologyReq := &TopologyRequest{
-		ServiceID:   req.ServiceID,
-		ServiceName: req.ServiceName,
-		Duration:    req.Duration,
+// queryProcessTopology queries the topology of the processes of a service instance from SkyWalking OAP
+func queryProcessTopology(ctx context.Context, req *ProcessTopologyRequest) (*mcp.CallToolResult, error) {
+	if req.ServiceInstanceID == "" {
+		return mcp.NewToolResultError(ErrMissingProcessTopologyScope), nil
+	}
+	if err := validateTopologyFormat(req.Format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := req.validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	return queryTopology(ctx, topologyReq, "Endpoint")
+	serviceID, err := ResolveServiceID(ctx, req.ServiceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	instanceID, err := ResolveInstanceID(ctx, serviceID, req.ServiceInstanceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	duration := topologyDuration(req.Duration)
+	var data struct {
+		Topology api.ProcessTopology `json:"topology"`
+	}
+	variables := map[string]interface{}{"serviceInstanceId": instanceID, "duration": duration}
+	if err := queryGraphQL(ctx, processTopologyQuery, variables, &data); err != nil {
+		return oapErrorResult("query Process topology", err), nil
+	}
+
+	nodes := make(map[string]*TopologyNode, len(data.Topology.Nodes))
+	for _, node := range data.Topology.Nodes {
+		nodes[node.ID] = processNode(node)
+	}
+	calls := make(map[string]*TopologyCall, len(data.Topology.Calls))
+	for _, call := range data.Topology.Calls {
+		calls[call.ID] = topologyCall(call)
+	}
+	topology := newTopology(nodes, calls)
+	if err := annotateTopology(ctx, topology, &req.TopologyMetricsRequest, duration); err != nil {
+		return oapErrorResult("query the metrics of the Process topology", err), nil
+	}
+	return topologyResult(topology, req.Format), nil
 }
 
-// queryTopology is the generic topology query function
-func queryTopology(ctx context.Context, req *TopologyRequest, topologyType string) (*mcp.CallToolResult, error) {
-	if err := validateTopologyRequest(req); err != nil {
+// queryGlobalTopology queries the topology of all services from SkyWalking OAP
+func queryGlobalTopology(ctx context.Context, req *GlobalTopologyRequest) (*mcp.CallToolResult, error) {
+	if req.MaxCalls == 0 {
+		req.MaxCalls = DefaultGlobalTopologyMaxCalls
+	}
+	if req.MaxCalls < 0 {
+		return mcp.NewToolResultError(ErrInvalidMaxCalls), nil
+	}
+	if err := validateTopologyFormat(req.Format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := req.validate(); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Build duration
-	var duration api.Duration
-	if req.Duration != "" {
-		duration = ParseDuration(req.Duration, false)
-	} else {
-		duration = BuildDuration("", "", "", false, DefaultDuration)
-	}
-
-	// Determine service ID
-	serviceID := req.ServiceID
-	if serviceID == "" && req.ServiceName != "" {
-		// We'll use the service name in the query
-		serviceID = req.ServiceName
-	}
-
-	// Build GraphQL query based on topology type
-	var query string
-	var variables map[string]interface{}
-
-	switch topologyType {
-	case "Service":
-		query = `
-			query getServiceTopology($serviceId: ID!, $duration: Duration!) {
-				serviceTopology: getServiceTopology(serviceId: $serviceId, duration: $duration) {
-					nodes {
-						id
-						name
-						type
-						isReal
-					}
-					calls {
-						id
-						source
-						target
-						sourceComponents
-						targetComponents
-						detectPoints
-					}
-				}
+	duration := topologyDuration(req.Duration)
+	var data struct {
+		Topology api.Topology `json:"topology"`
+	}
+	variables := map[string]interface{}{"duration": duration}
+	if req.Layer != "" {
+		variables["layer"] = req.Layer
+	}
+	if err := queryGraphQL(ctx, globalTopologyQuery, variables, &data); err != nil {
+		return oapErrorResult("query global topology", err), nil
+	}
+
+	nodes := make(map[string]*TopologyNode, len(data.Topology.Nodes))
+	for _, node := range data.Topology.Nodes {
+		nodes[node.ID] = serviceNode(node)
+	}
+	calls := make(map[string]*TopologyCall, len(data.Topology.Calls))
+	for _, call := range data.Topology.Calls {
+		calls[call.ID] = topologyCall(call)
+	}
+
+	// the traffic of every call ranks the calls kept when the topology is capped
+	if err := setCallTraffic(ctx, calls, duration); err != nil {
+		return oapErrorResult("query the traffic of the global topology", err), nil
+	}
+	if req.CollapseGroups {
+		nodes, calls = collapseGroups(nodes, calls)
+	}
+	// metrics are evaluated last, only for the nodes and calls that are returned
+	topology := capTopology(newTopology(nodes, calls), req.MaxCalls)
+	if err := annotateTopology(ctx, topology, &req.TopologyMetricsRequest, duration); err != nil {
+		return oapErrorResult("query the metrics of the global topology", err), nil
+	}
+	return topologyResult(topology, req.Format), nil
+}
+
+// setCallTraffic sets the calls per minute of every call between services, with a single batch of MQE expressions.
+// The server side metric is used when the server is instrumented, the client side metric otherwise.
+func setCallTraffic(ctx context.Context, calls map[string]*TopologyCall, duration api.Duration) error {
+	var queries []mqeBatchQuery
+	var targets []*TopologyCall
+	for _, call := range calls {
+		entity, ok := relationEntity(call.Source, call.Target)
+		if !ok {
+			continue
+		}
+		expression := "avg(service_relation_client_cpm)"
+		if slices.Contains(call.DetectPoints, string(api.DetectPointServer)) {
+			expression = "avg(service_relation_server_cpm)"
+		}
+		queries = append(queries, mqeBatchQuery{Expression: expression, Entity: entity})
+		targets = append(targets, call)
+	}
+
+	values, err := execMQEBatch(ctx, queries, duration)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		targets[i].CPM = value.Value
+	}
+	return nil
+}
+
+// validate checks the number of expressions and that none is empty
+func (req *TopologyMetricsRequest) validate() error {
+	if len(req.NodeMetrics) > MaxTopologyMetrics || len(req.CallMetrics) > MaxTopologyMetrics {
+		return fmt.Errorf(ErrTooManyTopologyMetrics, MaxTopologyMetrics)
+	}
+	if slices.Contains(req.NodeMetrics, "") || slices.Contains(req.CallMetrics, "") {
+		return errors.New(ErrEmptyTopologyMetric)
+	}
+	return nil
+}
+
+// annotateTopology evaluates the node and call expressions for every node and call of the topology,
+// all in a single batch of MQE expressions. Nodes and calls merged by collapsing have no metrics.
+func annotateTopology(ctx context.Context, topology *Topology, req *TopologyMetricsRequest, duration api.Duration) error {
+	if len(req.NodeMetrics) == 0 && len(req.CallMetrics) == 0 {
+		return nil
+	}
+
+	nodes := make(map[string]*TopologyNode, len(topology.Nodes))
+	for _, node := range topology.Nodes {
+		nodes[node.ID] = node
+	}
+
+	var queries []mqeBatchQuery
+	var targets []map[string]*float64
+	add := func(metrics map[string]*float64, entity map[string]interface{}, expressions []string) {
+		for _, expression := range expressions {
+			queries = append(queries, mqeBatchQuery{Expression: expression, Entity: entity})
+			targets = append(targets, metrics)
+			metrics[expression] = nil
+		}
+	}
+	for _, node := range topology.Nodes {
+		if entity, ok := nodeEntity(node); ok && len(req.NodeMetrics) > 0 {
+			node.Metrics = make(map[string]*float64, len(req.NodeMetrics))
+			add(node.Metrics, entity, req.NodeMetrics)
+		}
+	}
+	for _, call := range topology.Calls {
+		if entity, ok := callEntity(call, nodes); ok && len(req.CallMetrics) > 0 {
+			call.Metrics = make(map[string]*float64, len(req.CallMetrics))
+			add(call.Metrics, entity, req.CallMetrics)
+		}
+	}
+
+	values, err := execMQEBatch(ctx, queries, duration)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if value.Error != "" {
+			if topology.MetricErrors == nil {
+				topology.MetricErrors = make(map[string]string)
 			}
-		`
-		variables = map[string]interface{}{
-			"serviceId": serviceID,
-			"duration": map[string]interface{}{
-				"start": duration.Start,
-				"end":   duration.End,
-				"step":  string(duration.Step),
-			},
-		}
-	case "Instance":
-		query = `
-			query getServiceInstanceTopology($serviceId: ID!, $duration: Duration!) {
-				instanceTopology: getServiceInstanceTopology(serviceId: $serviceId, duration: $duration) {
-					nodes {
-						id
-						name
-						serviceId
-						serviceName
-					}
-					calls {
-						id
-						source
-						target
-					}
-				}
+			topology.MetricErrors[queries[i].Expression] = value.Error
+			continue
+		}
+		targets[i][queries[i].Expression] = value.Value
+	}
+	return nil
+}
+
+// nodeEntity builds the MQE entity of a service, service instance or process node
+func nodeEntity(node *TopologyNode) (map[string]interface{}, bool) {
+	if node.ServiceID != "" {
+		service, normal, err := ParseServiceID(node.ServiceID)
+		if err != nil || service == "" {
+			return nil, false
+		}
+		if node.ServiceInstanceName != "" {
+			return map[string]interface{}{
+				"scope":               string(api.ScopeProcess),
+				"serviceName":         service,
+				"normal":              normal,
+				"serviceInstanceName": node.ServiceInstanceName,
+				"processName":         node.Name,
+			}, true
+		}
+		return map[string]interface{}{
+			"scope":               string(api.ScopeServiceInstance),
+			"serviceName":         service,
+			"normal":              normal,
+			"serviceInstanceName": node.Name,
+		}, true
+	}
+
+	service, normal, err := ParseServiceID(node.ID)
+	if err != nil || service == "" {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"scope":       string(api.ScopeService),
+		"serviceName": service,
+		"normal":      normal,
+	}, true
+}
+
+// callEntity builds the MQE entity of a call between two services, two service instances or two processes
+func callEntity(call *TopologyCall, nodes map[string]*TopologyNode) (map[string]interface{}, bool) {
+	source, target := nodes[call.Source], nodes[call.Target]
+	if source == nil || target == nil || source.ServiceID == "" || target.ServiceID == "" {
+		return relationEntity(call.Source, call.Target)
+	}
+
+	entity, ok := relationEntity(source.ServiceID, target.ServiceID)
+	if !ok {
+		return nil, false
+	}
+	if source.ServiceInstanceName != "" && target.ServiceInstanceName != "" {
+		entity["scope"] = string(api.ScopeProcessRelation)
+		entity["serviceInstanceName"] = source.ServiceInstanceName
+		entity["processName"] = source.Name
+		entity["destServiceInstanceName"] = target.ServiceInstanceName
+		entity["destProcessName"] = target.Name
+		return entity, true
+	}
+	entity["scope"] = string(api.ScopeServiceInstanceRelation)
+	entity["serviceInstanceName"] = source.Name
+	entity["destServiceInstanceName"] = target.Name
+	return entity, true
+}
+
+// relationEntity builds the MQE entity of the calls between two services from their IDs
+func relationEntity(sourceID, targetID string) (map[string]interface{}, bool) {
+	source, sourceNormal, err := ParseServiceID(sourceID)
+	if err != nil || source == "" {
+		return nil, false
+	}
+	target, targetNormal, err := ParseServiceID(targetID)
+	if err != nil || target == "" {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"scope":           string(api.ScopeServiceRelation),
+		"serviceName":     source,
+		"normal":          sourceNormal,
+		"destServiceName": target,
+		"destNormal":      targetNormal,
+	}, true
+}
+
+// serviceGroup returns the group of a service name, e.g. "agent" of "agent::order-service"
+func serviceGroup(name string) (string, bool) {
+	group, _, ok := strings.Cut(name, groupSeparator)
+	return group, ok && group != ""
+}
+
+// collapseGroups merges the services of every service group into a single node,
+// and the calls between two groups into a single call. Services without a group are kept.
+func collapseGroups(nodes map[string]*TopologyNode, calls map[string]*TopologyCall) (map[string]*TopologyNode, map[string]*TopologyCall) {
+	nodeIDs := make(map[string]string, len(nodes))
+	collapsedNodes := make(map[string]*TopologyNode)
+	for id, node := range nodes {
+		group, ok := serviceGroup(node.Name)
+		if !ok {
+			nodeIDs[id] = id
+			collapsedNodes[id] = node
+			continue
+		}
+
+		groupID := group + groupSeparator
+		nodeIDs[id] = groupID
+		groupNode, ok := collapsedNodes[groupID]
+		if !ok {
+			groupNode = &TopologyNode{ID: groupID, Name: group}
+			collapsedNodes[groupID] = groupNode
+		}
+		groupNode.IsReal = groupNode.IsReal || node.IsReal
+		groupNode.Members = append(groupNode.Members, node.Name)
+		for _, layer := range node.Layers {
+			if !slices.Contains(groupNode.Layers, layer) {
+				groupNode.Layers = append(groupNode.Layers, layer)
 			}
-		`
-		variables = map[string]interface{}{
-			"serviceId": serviceID,
-			"duration": map[string]interface{}{
-				"start": duration.Start,
-				"end":   duration.End,
-				"step":  string(duration.Step),
-			},
-		}
-	case "Endpoint":
-		query = `
-			query getEndpointTopology($serviceId: ID!, $duration: Duration!) {
-				endpointTopology: getEndpointTopology(serviceId: $serviceId, duration: $duration) {
-					nodes {
-						id
-						name
-						serviceId
-						serviceName
-					}
-					calls {
-						id
-						source
-						target
-					}
-				}
+		}
+	}
+	for _, node := range collapsedNodes {
+		sort.Strings(node.Members)
+		sort.Strings(node.Layers)
+	}
+
+	collapsedCalls := make(map[string]*TopologyCall)
+	for _, call := range calls {
+		source, target := nodeIDs[call.Source], nodeIDs[call.Target]
+		if source == "" || target == "" {
+			continue
+		}
+		if source == target && source != call.Source {
+			collapsedNodes[source].InternalCalls++
+			continue
+		}
+		if source == call.Source && target == call.Target {
+			collapsedCalls[call.ID] = call
+			continue
+		}
+
+		id := source + "-" + target
+		merged, ok := collapsedCalls[id]
+		if !ok {
+			merged = &TopologyCall{ID: id, Source: source, Target: target}
+			collapsedCalls[id] = merged
+		}
+		merged.Merged++
+		merged.DetectPoints = mergeStrings(merged.DetectPoints, call.DetectPoints)
+		merged.SourceComponents = mergeStrings(merged.SourceComponents, call.SourceComponents)
+		merged.TargetComponents = mergeStrings(merged.TargetComponents, call.TargetComponents)
+		if call.CPM != nil {
+			cpm := *call.CPM
+			if merged.CPM != nil {
+				cpm += *merged.CPM
 			}
-		`
-		variables = map[string]interface{}{
-			"serviceId": serviceID,
-			"duration": map[string]interface{}{
-				"start": duration.Start,
-				"end":   duration.End,
-				"step":  string(duration.Step),
-			},
+			merged.CPM = &cpm
 		}
-	default:
-		return mcp.NewToolResultError(fmt.Sprintf("unknown topology type: %s", topologyType)), nil
 	}
+	return collapsedNodes, collapsedCalls
+}
 
-	result, err := executeGraphQL(ctx, viper.GetString("url"), query, variables)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to query %s topology: %v", topologyType, err)), nil
+// mergeStrings returns the sorted union of two string lists
+func mergeStrings(a, b []string) []string {
+	merged := slices.Clone(a)
+	for _, s := range b {
+		if !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// capTopology keeps the maxCalls calls with the most traffic and the nodes they connect,
+// and summarizes the omitted calls. Nodes without any call are always kept.
+func capTopology(topology *Topology, maxCalls int) *Topology {
+	if len(topology.Calls) <= maxCalls {
+		return topology
+	}
+
+	calls := slices.Clone(topology.Calls)
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i].CPM, calls[j].CPM
+		return a != nil && (b == nil || *a > *b)
+	})
+
+	summary := &TopologySummary{OmittedCalls: len(calls) - maxCalls}
+	linked := make(map[string]bool)
+	connected := make(map[string]bool)
+	keptCalls := make(map[*TopologyCall]bool, maxCalls)
+	for i, call := range calls {
+		linked[call.Source], linked[call.Target] = true, true
+		if i < maxCalls {
+			keptCalls[call] = true
+			connected[call.Source], connected[call.Target] = true, true
+			continue
+		}
+		if call.CPM != nil {
+			summary.OmittedCPM += *call.CPM
+		}
 	}
 
-	jsonBytes, err := json.Marshal(result.Data)
+	capped := &Topology{Summary: summary}
+	for _, call := range topology.Calls {
+		if keptCalls[call] {
+			capped.Calls = append(capped.Calls, call)
+		}
+	}
+	for _, node := range topology.Nodes {
+		// nodes only connected by omitted calls are summarized too
+		if connected[node.ID] || !linked[node.ID] {
+			capped.Nodes = append(capped.Nodes, node)
+		} else {
+			summary.OmittedNodes++
+		}
+	}
+	return capped
+}
+
+// serviceNode converts a service node returned by OAP
+func serviceNode(node *api.Node) *TopologyNode {
+	return &TopologyNode{
+		ID:     node.ID,
+		Name:   node.Name,
+		Type:   stringValue(node.Type),
+		IsReal: node.IsReal,
+		Layers: node.Layers,
+	}
+}
+
+// instanceNode converts a service instance node returned by OAP
+func instanceNode(node *api.ServiceInstanceNode) *TopologyNode {
+	return &TopologyNode{
+		ID:          node.ID,
+		Name:        node.Name,
+		Type:        stringValue(node.Type),
+		IsReal:      node.IsReal,
+		ServiceID:   node.ServiceID,
+		ServiceName: node.ServiceName,
+	}
+}
+
+// endpointNode converts an endpoint node returned by OAP
+func endpointNode(node *api.EndpointNode) *TopologyNode {
+	return &TopologyNode{
+		ID:          node.ID,
+		Name:        node.Name,
+		Type:        stringValue(node.Type),
+		IsReal:      node.IsReal,
+		ServiceID:   node.ServiceID,
+		ServiceName: node.ServiceName,
+	}
+}
+
+// processNode converts a process node returned by OAP
+func processNode(node *api.ProcessNode) *TopologyNode {
+	return &TopologyNode{
+		ID:                  node.ID,
+		Name:                node.Name,
+		IsReal:              node.IsReal,
+		ServiceID:           node.ServiceID,
+		ServiceName:         node.ServiceName,
+		ServiceInstanceID:   node.ServiceInstanceID,
+		ServiceInstanceName: node.ServiceInstanceName,
+	}
+}
+
+// topologyCall converts a call returned by OAP
+func topologyCall(call *api.Call) *TopologyCall {
+	detectPoints := make([]string, len(call.DetectPoints))
+	for i, point := range call.DetectPoints {
+		detectPoints[i] = string(point)
+	}
+	return &TopologyCall{
+		ID:               call.ID,
+		Source:           call.Source,
+		Target:           call.Target,
+		SourceComponents: call.SourceComponents,
+		TargetComponents: call.TargetComponents,
+		DetectPoints:     detectPoints,
+	}
+}
+
+// newTopology builds a topology with nodes ordered by hops and name, and calls ordered by source and target
+func newTopology(nodes map[string]*TopologyNode, calls map[string]*TopologyCall) *Topology {
+	topology := &Topology{
+		Nodes: make([]*TopologyNode, 0, len(nodes)),
+		Calls: make([]*TopologyCall, 0, len(calls)),
+	}
+	for _, node := range nodes {
+		topology.Nodes = append(topology.Nodes, node)
+	}
+	for _, call := range calls {
+		topology.Calls = append(topology.Calls, call)
+	}
+
+	sort.Slice(topology.Nodes, func(i, j int) bool {
+		a, b := topology.Nodes[i], topology.Nodes[j]
+		if a.Hops != nil && b.Hops != nil && *a.Hops != *b.Hops {
+			return *a.Hops < *b.Hops
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(topology.Calls, func(i, j int) bool {
+		a, b := topology.Calls[i], topology.Calls[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Target < b.Target
+	})
+	return topology
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// queryEndpointTopology queries endpoint topology from SkyWalking OAP
+func queryEndpointTopology(ctx context.Context, req *EndpointTopologyRequest) (*mcp.CallToolResult, error) {
+	if err := validateTopologyRequest(&TopologyRequest{ServiceID: req.ServiceID, ServiceName: req.ServiceName}); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := validateTopologyFormat(req.Format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// OAP only accepts service IDs, names are resolved across all layers
+	serviceID, err := ResolveServiceID(ctx, cmp.Or(req.ServiceID, req.ServiceName))
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var data struct {
+		Topology api.EndpointTopology `json:"topology"`
+	}
+	variables := map[string]interface{}{"serviceId": serviceID, "duration": topologyDuration(req.Duration)}
+	if err := queryGraphQL(ctx, endpointTopologyQuery, variables, &data); err != nil {
+		return oapErrorResult("query Endpoint topology", err), nil
 	}
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	nodes := make(map[string]*TopologyNode, len(data.Topology.Nodes))
+	for _, node := range data.Topology.Nodes {
+		nodes[node.ID] = endpointNode(node)
+	}
+	calls := make(map[string]*TopologyCall, len(data.Topology.Calls))
+	for _, call := range data.Topology.Calls {
+		calls[call.ID] = topologyCall(call)
+	}
+	return topologyResult(newTopology(nodes, calls), req.Format), nil
+}
+
+// formatOption is the format parameter of the topology tools
+func formatOption() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Enum(TopologyFormatJSON, TopologyFormatMermaid, TopologyFormatDOT, TopologyFormatAdjacency),
+		mcp.Description("Output format: \"json\" (default), \"mermaid\" flowchart, Graphviz \"dot\", or a compact \"adjacency\" list. "+
+			"Node labels show the type, call labels show the components, detect points, traffic and metrics."),
+	)
+}
+
+// nodeMetricsOption is the node_metrics parameter of the topology tools
+func nodeMetricsOption(example string) mcp.ToolOption {
+	return mcp.WithArray("node_metrics", mcp.WithStringItems(),
+		mcp.Description(fmt.Sprintf("MQE expressions evaluated for every node, e.g. [%s]. "+
+			"Time series are averaged over the duration. At most %d.", example, MaxTopologyMetrics)),
+	)
+}
+
+// callMetricsOption is the call_metrics parameter of the topology tools
+func callMetricsOption(example string) mcp.ToolOption {
+	return mcp.WithArray("call_metrics", mcp.WithStringItems(),
+		mcp.Description(fmt.Sprintf("MQE expressions evaluated for every call, e.g. [%s]. "+
+			"Time series are averaged over the duration. At most %d.", example, MaxTopologyMetrics)),
+	)
 }
 
 // ServiceTopologyTool is a tool for querying service topology
@@ -245,37 +1005,91 @@ Workflow:
 4. Understand microservice architecture
 
 Topology Information:
-- Nodes: Services with their types (e.g., HTTP, RPC, Database)
+- Nodes: Services with their types (e.g., HTTP, RPC, Database) and hops from the queried service
 - Calls: Relationships between services (source -> target)
-- Metrics: Optional metrics on calls (response time, throughput)
+- Metrics: Optional metrics on nodes and calls (response time, throughput), from the MQE expressions
+  in node_metrics and call_metrics, all evaluated in one batched request
+
+Depth:
+- 1 returns the direct callers and callees of the service
+- N expands the neighbors breadth-first up to N hops, one OAP query per hop
 
 Examples:
 - {"service_id": "your-service-id", "duration": "-1h"}: Service topology for the past hour
 - {"service_name": "user-service", "duration": "-24h"}: Topology by service name for last 24 hours
-- {"service_id": "order-service", "depth": 2}: Topology with depth 2 (2-hop relationships)`,
+- {"service_id": "b3JkZXItc2VydmljZQ==.1", "depth": 2}: Topology with depth 2 (2-hop relationships)
+- {"service_name": "agent::order-service", "node_metrics": ["service_cpm"],
+  "call_metrics": ["service_relation_client_cpm", "service_relation_server_resp_time"]}: Topology with traffic and latency
+- {"service_name": "agent::order-service", "depth": 2, "format": "mermaid"}: Mermaid diagram to paste into an incident doc`,
 	queryServiceTopology,
 	mcp.WithTitleAnnotation("Query service topology"),
 	mcp.WithString("service_id",
-		mcp.Description("Service ID to query topology for. Use service_id if you have the exact ID."),
+		mcp.Description("Service ID or name to query topology for."),
 	),
 	mcp.WithString("service_name",
-		mcp.Description("Service name to query topology for. Alternative to service_id."),
+		mcp.Description("Service name to query topology for. Alternative to service_id, resolved across all layers."),
 	),
 	mcp.WithString("duration",
 		mcp.Description("Time duration for the query. Examples: \"-1h\" (past hour), \"-24h\" (past 24 hours), \"-7d\" (past week). Default is last 30 minutes."),
 	),
 	mcp.WithNumber("depth",
-		mcp.Description("Depth of topology to fetch (number of hops). Default is 1."),
+		mcp.Description(fmt.Sprintf("Depth of topology to fetch (number of hops), from 1 to %d. Default is 1.", MaxTopologyDepth)),
+	),
+	nodeMetricsOption(`"service_cpm", "service_sla"`),
+	callMetricsOption(`"service_relation_client_cpm", "service_relation_server_resp_time"`),
+	formatOption(),
+)
+
+// GlobalTopologyTool is a tool for querying the topology of all services
+var GlobalTopologyTool = NewTool[GlobalTopologyRequest, *mcp.CallToolResult](
+	"get_global_topology",
+	`Get the topology of all services, or of the services of a layer.
+
+This tool retrieves the global topology graph from SkyWalking, showing how all services
+interact with each other. Use it during incidents to get the whole picture before
+zooming into a service with get_service_topology.
+
+Large Topologies:
+- Every call carries its traffic in calls per minute (cpm), averaged over the duration
+- Only the max_calls calls with the most traffic are returned, the others are summarized
+  in "summary" with their count, total cpm and the nodes only they connect
+- collapse_groups merges the services of a service group (e.g. agent::order-service and
+  agent::payment-service into "agent::") into one node, listing the services as members
+  and counting the calls within the group as internal_calls
+
+Examples:
+- {"duration": "-1h"}: Topology of all services in the past hour
+- {"layer": "MESH"}: Topology of the service mesh
+- {"collapse_groups": true, "max_calls": 20}: Overview of the 20 busiest calls between service groups
+- {"layer": "GENERAL", "format": "adjacency"}: Compact list of the callees of every service`,
+	queryGlobalTopology,
+	mcp.WithTitleAnnotation("Query global topology"),
+	mcp.WithString("layer",
+		mcp.Description("Only the services of the layer, e.g. GENERAL, MESH, K8S_SERVICE. Default is all layers, see list_layers."),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Time duration for the query. Examples: \"-1h\" (past hour), \"-24h\" (past 24 hours). Default is last 30 minutes."),
+	),
+	mcp.WithBoolean("collapse_groups",
+		mcp.Description("Merge the services of every service group into a single node. Default is false."),
+	),
+	mcp.WithNumber("max_calls",
+		mcp.Description(fmt.Sprintf("Maximum number of calls returned, the calls with the least traffic are summarized. Default is %d.",
+			DefaultGlobalTopologyMaxCalls)),
 	),
+	nodeMetricsOption(`"service_cpm", "service_sla"`),
+	callMetricsOption(`"service_relation_server_resp_time"`),
+	formatOption(),
 )
 
 // InstanceTopologyTool is a tool for querying service instance topology
 var InstanceTopologyTool = NewTool[InstanceTopologyRequest, *mcp.CallToolResult](
 	"get_instance_topology",
-	`Get service instance topology showing instance-level relationships.
+	`Get service instance topology showing instance-level relationships between two services.
 
-This tool retrieves the instance topology graph, showing how instances of services
-interact with each other. Useful for understanding service mesh and load balancing.
+This tool retrieves the instance topology graph of a client and a server service, showing
+which instances of the source service call which instances of the destination service.
+Useful for understanding service mesh and load balancing.
 
 Workflow:
 1. Use this tool to visualize instance relationships
@@ -283,20 +1097,65 @@ Workflow:
 3. Debug load balancing issues
 4. Understand instance distribution
 
+Use get_service_topology first to find the pairs of services that call each other.
+
 Examples:
-- {"service_id": "your-service-id", "duration": "-1h"}: Instance topology for the past hour
-- {"service_name": "user-service", "duration": "-30m"}: Instance topology by service name`,
+- {"source_service": "agent::gateway", "dest_service": "agent::order-service", "duration": "-1h"}:
+  Calls from gateway instances to order-service instances in the past hour
+- {"source_service": "Z2F0ZXdheQ==.1", "dest_service": "b3JkZXI=.1"}: Instance topology by service IDs`,
 	queryInstanceTopology,
 	mcp.WithTitleAnnotation("Query service instance topology"),
+	mcp.WithString("source_service", mcp.Required(),
+		mcp.Description("ID or name of the client service of the calls."),
+	),
+	mcp.WithString("dest_service", mcp.Required(),
+		mcp.Description("ID or name of the server service of the calls."),
+	),
+	mcp.WithString("duration",
+		mcp.Description("Time duration for the query. Examples: \"-1h\", \"-30m\". Default is last 30 minutes."),
+	),
+	nodeMetricsOption(`"service_instance_cpm", "service_instance_resp_time"`),
+	callMetricsOption(`"service_instance_relation_client_cpm", "service_instance_relation_server_resp_time"`),
+	formatOption(),
+)
+
+// ProcessTopologyTool is a tool for querying the process topology of a service instance
+var ProcessTopologyTool = NewTool[ProcessTopologyRequest, *mcp.CallToolResult](
+	"get_process_topology",
+	`Get the topology of the processes of a service instance, e.g. the containers of a Kubernetes pod.
+
+This tool retrieves the process topology from SkyWalking, built from the network traffic
+captured by eBPF network profiling tasks of SkyWalking Rover. It shows which processes of
+the instance talk to each other and to remote addresses, without any agent in the processes.
+
+Workflow:
+1. Use list_network_profiling_tasks to check that the instance is being profiled
+2. Use list_processes to find the processes of the instance
+3. Use this tool to see the calls between the processes
+4. Annotate the processes with Process metrics in node_metrics and the calls with ProcessRelation metrics
+   in call_metrics, list_mqe_metrics with {regex: "process_.*"} lists the metrics reported by OAP
+
+Nodes that are not real are remote addresses outside the instance.
+
+Examples:
+- {"service_id": "agent::order-service", "service_instance_id": "order-7d9f-x2v4", "duration": "-30m"}:
+  Process topology of a pod in the past 30 minutes
+- {"service_instance_id": "b3JkZXI=.1_cG9k", "call_metrics": ["process_relation_http1_request_cpm"]}:
+  Process topology with the HTTP/1.x request rate of every call`,
+	queryProcessTopology,
+	mcp.WithTitleAnnotation("Query process topology"),
 	mcp.WithString("service_id",
-		mcp.Description("Service ID to query instance topology for."),
+		mcp.Description("Service ID or name, required when service_instance_id is a name."),
 	),
-	mcp.WithString("service_name",
-		mcp.Description("Service name to query instance topology for."),
+	mcp.WithString("service_instance_id", mcp.Required(),
+		mcp.Description("Service instance ID or name, see list_instances."),
 	),
 	mcp.WithString("duration",
 		mcp.Description("Time duration for the query. Examples: \"-1h\", \"-30m\". Default is last 30 minutes."),
 	),
+	nodeMetricsOption(`Process metrics found with list_mqe_metrics`),
+	callMetricsOption(`"process_relation_client_write_cpm", "process_relation_http1_request_cpm"`),
+	formatOption(),
 )
 
 // EndpointTopologyTool is a tool for querying endpoint topology
@@ -315,7 +1174,8 @@ Workflow:
 
 Examples:
 - {"service_id": "your-service-id", "duration": "-1h"}: Endpoint topology for the past hour
-- {"service_name": "api-gateway", "duration": "-24h"}: Endpoint topology by service name`,
+- {"service_name": "api-gateway", "duration": "-24h"}: Endpoint topology by service name
+- {"service_name": "api-gateway", "format": "dot"}: Graphviz diagram of the endpoint dependencies`,
 	queryEndpointTopology,
 	mcp.WithTitleAnnotation("Query endpoint topology"),
 	mcp.WithString("service_id",
@@ -327,4 +1187,5 @@ Examples:
 	mcp.WithString("duration",
 		mcp.Description("Time duration for the query. Examples: \"-1h\", \"-30m\". Default is last 30 minutes."),
 	),
+	formatOption(),
 )
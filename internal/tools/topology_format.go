@@ -0,0 +1,313 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Topology output formats
+const (
+	TopologyFormatJSON      = "json"
+	TopologyFormatMermaid   = "mermaid"
+	TopologyFormatDOT       = "dot"
+	TopologyFormatAdjacency = "adjacency"
+)
+
+// ErrInvalidTopologyFormat is returned for unknown output formats
+const ErrInvalidTopologyFormat = "invalid format %q, must be one of: json, mermaid, dot, adjacency"
+
+// validateTopologyFormat checks the output format of a topology tool
+func validateTopologyFormat(format string) error {
+	switch format {
+	case "", TopologyFormatJSON, TopologyFormatMermaid, TopologyFormatDOT, TopologyFormatAdjacency:
+		return nil
+	default:
+		return fmt.Errorf(ErrInvalidTopologyFormat, format)
+	}
+}
+
+// topologyResult renders the topology in the requested format
+func topologyResult(topology *Topology, format string) *mcp.CallToolResult {
+	switch format {
+	case TopologyFormatMermaid:
+		return mcp.NewToolResultText(renderMermaid(topology))
+	case TopologyFormatDOT:
+		return mcp.NewToolResultText(renderDOT(topology))
+	case TopologyFormatAdjacency:
+		return mcp.NewToolResultText(renderAdjacency(topology))
+	default:
+		return toolResultJSON(topology)
+	}
+}
+
+// nodeLabel returns the lines of the label of a node: the name, the type and the metrics
+func nodeLabel(node *TopologyNode) []string {
+	lines := []string{node.Name}
+	if node.Type != "" {
+		lines = append(lines, node.Type)
+	}
+	if !node.IsReal {
+		lines = append(lines, "virtual")
+	}
+	switch len(node.Members) {
+	case 0:
+	case 1:
+		lines = append(lines, "1 service")
+	default:
+		lines = append(lines, fmt.Sprintf("%d services", len(node.Members)))
+	}
+	return append(lines, metricLabels(node.Metrics)...)
+}
+
+// callLabel returns the lines of the label of a call: the components, the detect points, the traffic and the metrics
+func callLabel(call *TopologyCall) []string {
+	var lines []string
+	if components := mergeStrings(call.SourceComponents, call.TargetComponents); len(components) > 0 {
+		lines = append(lines, strings.Join(components, ", "))
+	}
+	if len(call.DetectPoints) > 0 {
+		lines = append(lines, strings.Join(call.DetectPoints, "/"))
+	}
+	if call.CPM != nil {
+		lines = append(lines, formatMetricValue(call.CPM)+" cpm")
+	}
+	return append(lines, metricLabels(call.Metrics)...)
+}
+
+// metricLabels formats metrics as sorted "expression=value" lines
+func metricLabels(metrics map[string]*float64) []string {
+	lines := make([]string, 0, len(metrics))
+	for expression, value := range metrics {
+		lines = append(lines, expression+"="+formatMetricValue(value))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// formatMetricValue formats a metric value with at most two decimals, "n/a" when OAP has no data
+func formatMetricValue(value *float64) string {
+	if value == nil {
+		return "n/a"
+	}
+	return strconv.FormatFloat(*value, 'f', 2, 64)
+}
+
+// nodeCluster returns the name of the box a node is drawn in: the instance of a process or the service of an instance
+func nodeCluster(node *TopologyNode) string {
+	if node.ServiceInstanceName != "" {
+		return node.ServiceInstanceName
+	}
+	return node.ServiceName
+}
+
+// clusterNodes groups the node indexes by cluster, in order of appearance, nodes without a cluster come first
+func clusterNodes(topology *Topology) (clusters []string, members map[string][]int) {
+	members = make(map[string][]int)
+	for i, node := range topology.Nodes {
+		cluster := nodeCluster(node)
+		if _, ok := members[cluster]; !ok && cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+		members[cluster] = append(members[cluster], i)
+	}
+	return clusters, members
+}
+
+// topologyNotes returns the omitted calls and the metric errors as comment lines
+func topologyNotes(topology *Topology) []string {
+	var notes []string
+	if s := topology.Summary; s != nil {
+		notes = append(notes, fmt.Sprintf("omitted %d calls (%s cpm) and %d nodes",
+			s.OmittedCalls, formatMetricValue(&s.OmittedCPM), s.OmittedNodes))
+	}
+	expressions := make([]string, 0, len(topology.MetricErrors))
+	for expression := range topology.MetricErrors {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+	for _, expression := range expressions {
+		notes = append(notes, fmt.Sprintf("error of %s: %s", expression, topology.MetricErrors[expression]))
+	}
+	return notes
+}
+
+// renderMermaid renders the topology as a Mermaid flowchart, virtual nodes are drawn as stadiums
+func renderMermaid(topology *Topology) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, note := range topologyNotes(topology) {
+		fmt.Fprintf(&b, "  %%%% %s\n", mermaidText(note))
+	}
+
+	ids := shortNodeIDs(topology)
+	writeNode := func(indent string, node *TopologyNode) {
+		label := mermaidLabel(nodeLabel(node))
+		if node.IsReal {
+			fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, ids[node.ID], label)
+		} else {
+			fmt.Fprintf(&b, "%s%s([\"%s\"])\n", indent, ids[node.ID], label)
+		}
+	}
+
+	clusters, members := clusterNodes(topology)
+	for _, i := range members[""] {
+		writeNode("  ", topology.Nodes[i])
+	}
+	for c, cluster := range clusters {
+		fmt.Fprintf(&b, "  subgraph c%d[\"%s\"]\n", c, mermaidText(cluster))
+		for _, i := range members[cluster] {
+			writeNode("    ", topology.Nodes[i])
+		}
+		b.WriteString("  end\n")
+	}
+
+	for _, call := range topology.Calls {
+		source, target := mermaidNodeID(ids, call.Source), mermaidNodeID(ids, call.Target)
+		if label := callLabel(call); len(label) > 0 {
+			fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", source, mermaidLabel(label), target)
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", source, target)
+		}
+	}
+	return b.String()
+}
+
+// shortNodeIDs numbers the nodes of the topology, the OAP IDs are long base64 strings
+func shortNodeIDs(topology *Topology) map[string]string {
+	ids := make(map[string]string, len(topology.Nodes))
+	for i, node := range topology.Nodes {
+		ids[node.ID] = "n" + strconv.Itoa(i)
+	}
+	return ids
+}
+
+// mermaidNodeID returns the Mermaid ID of a node, calls to nodes missing from the topology keep a sanitized OAP ID
+func mermaidNodeID(ids map[string]string, id string) string {
+	if mermaidID, ok := ids[id]; ok {
+		return mermaidID
+	}
+	return "x_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// mermaidLabel joins label lines with Mermaid line breaks
+func mermaidLabel(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = mermaidText(line)
+	}
+	return strings.Join(escaped, "<br/>")
+}
+
+// mermaidText escapes the characters that end a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// renderDOT renders the topology as a Graphviz digraph, virtual nodes are drawn dashed
+func renderDOT(topology *Topology) string {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, note := range topologyNotes(topology) {
+		fmt.Fprintf(&b, "  // %s\n", strings.ReplaceAll(note, "\n", " "))
+	}
+
+	ids := shortNodeIDs(topology)
+	writeNode := func(indent string, node *TopologyNode) {
+		style := ""
+		if !node.IsReal {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "%s%s [label=%s%s];\n", indent, ids[node.ID], dotQuote(strings.Join(nodeLabel(node), "\n")), style)
+	}
+
+	clusters, members := clusterNodes(topology)
+	for _, i := range members[""] {
+		writeNode("  ", topology.Nodes[i])
+	}
+	for c, cluster := range clusters {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", c, dotQuote(cluster))
+		for _, i := range members[cluster] {
+			writeNode("    ", topology.Nodes[i])
+		}
+		b.WriteString("  }\n")
+	}
+
+	for _, call := range topology.Calls {
+		fmt.Fprintf(&b, "  %s -> %s", cmp.Or(ids[call.Source], dotQuote(call.Source)), cmp.Or(ids[call.Target], dotQuote(call.Target)))
+		if label := callLabel(call); len(label) > 0 {
+			fmt.Fprintf(&b, " [label=%s]", dotQuote(strings.Join(label, "\n")))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a DOT ID or label, line breaks become centered DOT line breaks
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// renderAdjacency renders the topology as an adjacency list, one line per node followed by its outgoing calls
+func renderAdjacency(topology *Topology) string {
+	names := make(map[string]string, len(topology.Nodes))
+	for _, node := range topology.Nodes {
+		names[node.ID] = node.Name
+	}
+	outgoing := make(map[string][]*TopologyCall)
+	for _, call := range topology.Calls {
+		outgoing[call.Source] = append(outgoing[call.Source], call)
+	}
+
+	var b strings.Builder
+	for _, note := range topologyNotes(topology) {
+		fmt.Fprintf(&b, "# %s\n", note)
+	}
+	for _, node := range topology.Nodes {
+		label := nodeLabel(node)
+		b.WriteString(label[0])
+		if cluster := nodeCluster(node); cluster != "" {
+			fmt.Fprintf(&b, " @ %s", cluster)
+		}
+		if len(label) > 1 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(label[1:], "; "))
+		}
+		b.WriteString("\n")
+
+		for _, call := range outgoing[node.ID] {
+			fmt.Fprintf(&b, "  -> %s", cmp.Or(names[call.Target], call.Target))
+			if label := callLabel(call); len(label) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(label, "; "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
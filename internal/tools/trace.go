@@ -38,6 +38,7 @@ func AddTraceTools(mcp *server.MCPServer) {
 	SearchTraceTool.Register(mcp)
 	ColdTraceTool.Register(mcp)
 	TracesQueryTool.Register(mcp)
+	CompareTracesTool.Register(mcp)
 }
 
 // View constants
@@ -45,6 +46,9 @@ const (
 	ViewFull       = "full"
 	ViewSummary    = "summary"
 	ViewErrorsOnly = "errors_only"
+	ViewTree       = "tree"
+	ViewWaterfall  = "waterfall"
+	ViewFlamegraph = "flamegraph"
 )
 
 // Query order constants
@@ -73,7 +77,7 @@ const (
 	ErrInvalidTraceState      = "invalid trace_state '%s', available states: %s, %s, %s"
 	ErrInvalidQueryOrder      = "invalid query_order '%s', available orders: %s, %s"
 	ErrTraceNotFound          = "trace with ID '%s' not found"
-	ErrInvalidView            = "invalid view '%s', available views: %s, %s, %s"
+	ErrInvalidView            = "invalid view '%s', available views: %s"
 	ErrNoTracesFound          = "no traces found matching the query criteria"
 )
 
@@ -82,7 +86,7 @@ const TimeFormatFull = "2006-01-02 15:04:05"
 // Trace-specific constants
 const (
 	DefaultTracePageSize = 20
-	DefaultTraceDuration = "1h"
+	DefaultTraceDuration = time.Hour
 )
 
 // TraceRequest defines the parameters for the trace tool
@@ -121,6 +125,9 @@ type TracesQueryRequest struct {
 	SlowTraceThreshold int64     `json:"slow_trace_threshold,omitempty"`
 	Tags               []SpanTag `json:"tags,omitempty"`
 	Cold               bool      `json:"cold,omitempty"`
+	EnrichTraces       int       `json:"enrich_traces,omitempty"`
+	MaxTraces          int       `json:"max_traces,omitempty"`
+	Sampling           string    `json:"sampling,omitempty"`
 }
 
 // TraceSummary provides a high-level overview of a trace
@@ -138,28 +145,45 @@ type TraceSummary struct {
 
 // TracesSummary provides a high-level overview of multiple traces
 type TracesSummary struct {
-	TotalTraces  int                 `json:"total_traces"`
-	SuccessCount int                 `json:"success_count"`
-	ErrorCount   int                 `json:"error_count"`
-	Services     []string            `json:"services"`
-	Endpoints    []string            `json:"endpoints"`
-	AvgDuration  float64             `json:"avg_duration_ms"`
-	MinDuration  int64               `json:"min_duration_ms"`
-	MaxDuration  int64               `json:"max_duration_ms"`
-	TimeRange    TimeRange           `json:"time_range"`
-	ErrorTraces  []BasicTraceSummary `json:"error_traces,omitempty"`
-	SlowTraces   []BasicTraceSummary `json:"slow_traces,omitempty"`
+	TotalTraces  int `json:"total_traces"`
+	SuccessCount int `json:"success_count"`
+	ErrorCount   int `json:"error_count"`
+	// Services are only known for the enriched traces, the trace list of OAP has no services
+	Services    []string            `json:"services,omitempty"`
+	Endpoints   []string            `json:"endpoints"`
+	AvgDuration float64             `json:"avg_duration_ms"`
+	MinDuration int64               `json:"min_duration_ms"`
+	MaxDuration int64               `json:"max_duration_ms"`
+	TimeRange   TimeRange           `json:"time_range"`
+	ErrorTraces []BasicTraceSummary `json:"error_traces,omitempty"`
+	SlowTraces  []BasicTraceSummary `json:"slow_traces,omitempty"`
+	TopTraces   []BasicTraceSummary `json:"top_traces,omitempty"`
+
+	Percentiles *DurationPercentiles `json:"percentiles,omitempty"`
+	// Histogram counts the traces by duration, the bucket bounds are powers of two
+	Histogram     []HistogramBucket `json:"histogram,omitempty"`
+	EndpointStats []EndpointStats   `json:"endpoint_stats,omitempty"`
+	// OutlierThreshold is the duration above which traces are outliers, derived from the median absolute deviation
+	OutlierThreshold int64               `json:"outlier_threshold_ms,omitempty"`
+	OutlierCount     int                 `json:"outlier_count,omitempty"`
+	Outliers         []BasicTraceSummary `json:"outliers,omitempty"`
 }
 
 // BasicTraceSummary provides essential information about a single trace
+// The service, span count and error spans are only set when the trace was enriched with its spans.
 type BasicTraceSummary struct {
 	TraceID      string `json:"trace_id"`
-	ServiceName  string `json:"service_name"`
+	ServiceName  string `json:"service_name,omitempty"`
 	EndpointName string `json:"endpoint_name"`
 	StartTime    int64  `json:"start_time_ms"`
 	Duration     int64  `json:"duration_ms"`
 	IsError      bool   `json:"is_error"`
-	SpanCount    int    `json:"span_count"`
+	SpanCount    int    `json:"span_count,omitempty"`
+	// Services are all services the trace passed through
+	Services       []string            `json:"services,omitempty"`
+	ErrorSpanCount int                 `json:"error_span_count,omitempty"`
+	ErrorSpans     []ErrorSpanLocation `json:"error_spans,omitempty"`
+	EnrichError    string              `json:"enrich_error,omitempty"`
 }
 
 // TimeRange represents the time span of the traces
@@ -171,15 +195,16 @@ type TimeRange struct {
 
 // createBasicTraceSummary creates a BasicTraceSummary from trace item data
 func createBasicTraceSummary(traceItem *api.BasicTrace, startTimeMs, duration int64, isError bool) BasicTraceSummary {
-	return BasicTraceSummary{
-		TraceID:      traceItem.TraceIds[0], // Use first trace ID
-		ServiceName:  traceItem.SegmentID,   // Use segment ID as service name
+	summary := BasicTraceSummary{
 		EndpointName: strings.Join(traceItem.EndpointNames, ", "),
 		StartTime:    startTimeMs,
 		Duration:     duration,
 		IsError:      isError,
-		SpanCount:    0, // BasicTrace doesn't have span count
 	}
+	if len(traceItem.TraceIds) > 0 {
+		summary.TraceID = traceItem.TraceIds[0] // Use first trace ID
+	}
+	return summary
 }
 
 // processTraceResult handles the common logic for processing trace results
@@ -194,10 +219,17 @@ func processTraceResult(traceID string, traceData *api.Trace, view string) (*mcp
 		result = generateTraceSummary(traceID, traceData)
 	case ViewErrorsOnly:
 		result = filterErrorSpans(traceData)
+	case ViewTree:
+		result = buildTraceTree(traceID, traceData)
+	case ViewWaterfall:
+		return mcp.NewToolResultText(renderWaterfall(buildTraceTree(traceID, traceData))), nil
+	case ViewFlamegraph:
+		return mcp.NewToolResultText(renderFlamegraph(buildTraceTree(traceID, traceData))), nil
 	case ViewFull:
 		result = traceData
 	default:
-		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidView, view, ViewFull, ViewSummary, ViewErrorsOnly)), nil
+		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidView, view,
+			strings.Join([]string{ViewFull, ViewSummary, ViewErrorsOnly, ViewTree, ViewWaterfall, ViewFlamegraph}, ", "))), nil
 	}
 
 	jsonBytes, err := json.Marshal(result)
@@ -235,13 +267,18 @@ func searchTrace(ctx context.Context, req *TraceRequest) (*mcp.CallToolResult, e
 		req.View = ViewFull // Set default value
 	}
 
-	traces, err := trace.Trace(ctx, req.TraceID)
+	traceData, source, err := fetchTrace(ctx, req.TraceID, "")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToQueryTrace, req.TraceID, err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	traceData := &traces
 
-	return processTraceResult(req.TraceID, traceData, req.View)
+	result, err := processTraceResult(req.TraceID, traceData, req.View)
+	if err != nil || result.IsError {
+		return result, err
+	}
+	// the storage stage is reported separately to keep the output of every view unchanged
+	result.Content = append(result.Content, mcp.NewTextContent(source.String()))
+	return result, nil
 }
 
 // searchColdTrace fetches the trace data from cold storage and processes it based on the requested view
@@ -281,17 +318,16 @@ func generateTraceSummary(traceID string, traceData *api.Trace) *TraceSummary {
 		if span.IsError != nil && *span.IsError {
 			summary.ErrorCount++
 		}
-		// Heuristic to find the root span: a span with spanId 0 and parentSpanId -1
-		if span.SpanID == 0 && span.ParentSpanID == -1 {
-			if span.EndpointName != nil {
-				summary.RootEndpoint = *span.EndpointName
-			}
-			summary.StartTime = span.StartTime
-			summary.EndTime = span.EndTime
-			if summary.StartTime > 0 && summary.EndTime > 0 {
-				summary.TotalDuration = summary.EndTime - summary.StartTime
-			}
-		}
+	}
+
+	// The root span is the first span without a parent across segments,
+	// the duration covers all spans, including the asynchronous ones
+	tree := buildTraceTree(traceID, traceData)
+	if len(tree.Roots) > 0 {
+		summary.RootEndpoint = tree.Roots[0].Endpoint
+		summary.StartTime = tree.StartTime
+		summary.EndTime = tree.StartTime + tree.Duration
+		summary.TotalDuration = tree.Duration
 	}
 
 	summary.HasErrors = summary.ErrorCount > 0
@@ -334,8 +370,11 @@ func validateTracesQueryRequest(req *TracesQueryRequest) error {
 	if req.PageNum < 0 {
 		return errors.New(ErrNegativePageNum)
 	}
+	if req.EnrichTraces < 0 || req.EnrichTraces > MaxEnrichedTraces {
+		return fmt.Errorf(ErrInvalidEnrichTraces, req.EnrichTraces, MaxEnrichedTraces)
+	}
 
-	return nil
+	return validateTraceSampling(req)
 }
 
 // setBasicFields sets basic fields in the query condition
@@ -382,9 +421,9 @@ func setDuration(req *TracesQueryRequest, condition *api.TraceQueryCondition) {
 		duration := ParseDuration(req.Duration, req.Cold)
 		condition.QueryDuration = &duration
 	} else if req.TraceID == "" {
-		// If no duration and no traceId provided, set default duration (last 1 hour)
+		// If no duration and no traceId provided, set default duration (last 1 hour unless configured)
 		// SkyWalking OAP requires either queryDuration or traceId
-		defaultDuration := ParseDuration(DefaultTraceDuration, req.Cold)
+		defaultDuration := ParseDuration("-"+defaultTraceDuration.String(), req.Cold)
 		condition.QueryDuration = &defaultDuration
 	}
 }
@@ -465,6 +504,9 @@ func searchTraces(ctx context.Context, req *TracesQueryRequest) (*mcp.CallToolRe
 	if err := validateTracesQueryRequest(req); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
+	if err := resolveEntityIDs(ctx, &req.ServiceID, &req.ServiceInstanceID, &req.EndpointID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Set default view
 	if req.View == "" {
@@ -478,30 +520,35 @@ func searchTraces(ctx context.Context, req *TracesQueryRequest) (*mcp.CallToolRe
 	}
 
 	// Execute query
-	traces, err := trace.Traces(ctx, condition)
+	traces, err := collectTraces(ctx, req, condition)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf(ErrFailedToQueryTraces, err)), nil
 	}
 
-	return processTracesResult(&traces, req.View, req.SlowTraceThreshold)
+	return processTracesResult(ctx, &traces, req)
 }
 
 // processTracesResult handles the common logic for processing traces query results
-func processTracesResult(traces *api.TraceBrief, view string, slowTraceThreshold int64) (*mcp.CallToolResult, error) {
+func processTracesResult(ctx context.Context, traces *api.TraceBrief, req *TracesQueryRequest) (*mcp.CallToolResult, error) {
 	if traces == nil || len(traces.Traces) == 0 {
 		return mcp.NewToolResultError(ErrNoTracesFound), nil
 	}
 
 	var result interface{}
-	switch view {
+	switch view := req.View; view {
 	case ViewSummary:
-		result = generateTracesSummary(traces, slowTraceThreshold)
+		summary := generateTracesSummary(traces, req.SlowTraceThreshold)
+		if req.EnrichTraces > 0 {
+			enrichTracesSummary(ctx, summary, traces, req.EnrichTraces, traceColdDuration(req))
+		}
+		result = summary
 	case ViewErrorsOnly:
 		result = filterErrorTraces(traces)
 	case ViewFull:
 		result = traces
 	default:
-		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidView, view, ViewFull, ViewSummary, ViewErrorsOnly)), nil
+		return mcp.NewToolResultError(fmt.Sprintf(ErrInvalidView, view,
+			strings.Join([]string{ViewFull, ViewSummary, ViewErrorsOnly}, ", "))), nil
 	}
 
 	jsonBytes, err := json.Marshal(result)
@@ -511,9 +558,22 @@ func processTracesResult(traces *api.TraceBrief, view string, slowTraceThreshold
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// traceColdDuration returns the duration to fetch the spans of the traces found by a cold query,
+// empty for hot queries
+func traceColdDuration(req *TracesQueryRequest) string {
+	switch {
+	case !req.Cold:
+		return ""
+	case req.Duration != "":
+		return req.Duration
+	default:
+		return "-" + defaultTraceDuration.String()
+	}
+}
+
 // processTraceItem processes a single trace item and updates summary statistics
 func processTraceItem(traceItem *api.BasicTrace, summary *TracesSummary,
-	services, endpoints map[string]struct{}, durations *[]int64,
+	endpoints map[string]struct{}, durations *[]int64,
 	errorTraces, slowTraces *[]BasicTraceSummary, slowTraceThreshold int64,
 	minStartTime, maxEndTime *int64, totalDuration *int64) {
 	if traceItem == nil {
@@ -555,8 +615,7 @@ func processTraceItem(traceItem *api.BasicTrace, summary *TracesSummary,
 		*slowTraces = append(*slowTraces, createBasicTraceSummary(traceItem, startTimeMs, duration, isError))
 	}
 
-	// Collect services and endpoints
-	services[traceItem.SegmentID] = struct{}{}
+	// Collect endpoints
 	for _, endpoint := range traceItem.EndpointNames {
 		if endpoint != "" {
 			endpoints[endpoint] = struct{}{}
@@ -591,7 +650,6 @@ func generateTracesSummary(traces *api.TraceBrief, slowTraceThreshold int64) *Tr
 		TotalTraces: len(traces.Traces),
 	}
 
-	services := make(map[string]struct{})
 	endpoints := make(map[string]struct{})
 	var durations []int64
 	var errorTraces []BasicTraceSummary
@@ -602,13 +660,17 @@ func generateTracesSummary(traces *api.TraceBrief, slowTraceThreshold int64) *Tr
 
 	// Process each trace item
 	for _, traceItem := range traces.Traces {
-		processTraceItem(traceItem, summary, services, endpoints, &durations,
+		processTraceItem(traceItem, summary, endpoints, &durations,
 			&errorTraces, &slowTraces, slowTraceThreshold, &minStartTime, &maxEndTime, &totalDuration)
 	}
 
-	// Calculate statistics
+	// Calculate statistics, the durations are sorted from here on
 	summary.AvgDuration, summary.MinDuration, summary.MaxDuration =
 		calculateStatistics(durations, totalDuration)
+	summary.Percentiles = durationPercentiles(durations)
+	summary.Histogram = durationHistogram(durations)
+	summary.EndpointStats = endpointStatistics(traces)
+	summary.OutlierThreshold, summary.Outliers, summary.OutlierCount = durationOutliers(traces, durations)
 
 	// Set time range
 	summary.TimeRange = TimeRange{
@@ -618,13 +680,10 @@ func generateTracesSummary(traces *api.TraceBrief, slowTraceThreshold int64) *Tr
 	}
 
 	// Convert maps to slices
-	for service := range services {
-		summary.Services = append(summary.Services, service)
-	}
-	sort.Strings(summary.Services) // Ensure deterministic order
 	for endpoint := range endpoints {
 		summary.Endpoints = append(summary.Endpoints, endpoint)
 	}
+	sort.Strings(summary.Endpoints)
 
 	// Sort error and slow traces by duration (descending)
 	sort.Slice(errorTraces, func(i, j int) bool {
@@ -680,28 +739,45 @@ Workflow:
    - 'full': For complete trace analysis with all spans and details
    - 'summary': For quick overview and performance metrics
    - 'errors_only': For troubleshooting and error investigation
+   - 'tree': For finding which hop dominated the latency
+   - 'waterfall' or 'flamegraph': For large traces, as compact text
 
 Best Practices:
 - Use 'summary' view first to get an overview of the trace
 - Switch to 'errors_only' if the summary shows errors
+- Use 'tree' view to see the span hierarchy across services with the self time of every span,
+  the critical path and the bottleneck span with the most self time on it
+- Use 'waterfall' for an indented timeline of the spans with offsets, durations and self times,
+  where runs of identical sibling spans, e.g. queries in a loop, are collapsed into one line
+- Use 'flamegraph' for folded stacks with the self time of every stack in ms, for flame graph tools
 - Use 'full' view for detailed debugging and span-by-span analysis
 - Trace IDs are typically found in logs, error messages, or monitoring dashboards
 
+Storage:
+- Traces missing from hot storage are searched in cold storage over the last 1d, 7d and 30d,
+  up to the lookback configured on the server, until the trace is found
+- The storage stage that served the trace, hot or cold, is reported after the result
+
 Examples:
 - {"trace_id": "abc123..."}: Get complete trace details for analysis
 - {"trace_id": "abc123...", "view": "summary"}: Quick performance overview
-- {"trace_id": "abc123...", "view": "errors_only"}: Focus on error spans only`,
+- {"trace_id": "abc123...", "view": "errors_only"}: Focus on error spans only
+- {"trace_id": "abc123...", "view": "tree"}: Span hierarchy with the critical path
+- {"trace_id": "abc123...", "view": "waterfall"}: Timeline of a large trace`,
 	searchTrace,
 	mcp.WithTitleAnnotation("Search a trace by TraceId"),
 	mcp.WithString("trace_id", mcp.Required(),
 		mcp.Description(`The unique identifier of the trace to retrieve.`),
 	),
 	mcp.WithString("view",
-		mcp.Enum(ViewFull, ViewSummary, ViewErrorsOnly),
+		mcp.Enum(ViewFull, ViewSummary, ViewErrorsOnly, ViewTree, ViewWaterfall, ViewFlamegraph),
 		mcp.Description(`Specifies the level of detail for trace analysis:
 - 'full': (Default) Complete trace with all spans, service calls, and metadata
 - 'summary': High-level overview with services, duration, and error count
-- 'errors_only': Only spans marked as errors for troubleshooting`),
+- 'errors_only': Only spans marked as errors for troubleshooting
+- 'tree': Span hierarchy across segments with self times and the critical path
+- 'waterfall': Indented text timeline with offsets and durations, repeated sibling spans collapsed
+- 'flamegraph': Folded stacks with the self time in ms, compatible with flame graph tools`),
 	),
 )
 
@@ -714,7 +790,8 @@ Important Notes:
 - Only works with BanyanDB storage backend
 - Queries older trace data that has been moved to cold storage
 - May have slower response times compared to hot storage queries
-- Use when trace data is not found in regular trace queries
+- get_trace_details already searches cold storage for traces missing from hot storage, up to a configured lookback.
+  Use this tool to search a specific time range, or further back than that lookback
 
 Duration Format:
 - Standard Go duration: "7d", "1h", "-30m", "2h30m"
@@ -732,7 +809,8 @@ Examples:
 - {"trace_id": "abc123...", "duration": "7d"}: Search last 7 days of cold storage
 - {"trace_id": "abc123...", "duration": "-30m"}: Search from 30 minutes ago to now
 - {"trace_id": "abc123...", "duration": "1h", "view": "summary"}: Quick summary from last hour
-- {"trace_id": "abc123...", "duration": "2h30m", "view": "errors_only"}: Error analysis from last 2.5 hours`,
+- {"trace_id": "abc123...", "duration": "2h30m", "view": "errors_only"}: Error analysis from last 2.5 hours
+- {"trace_id": "abc123...", "duration": "30d", "view": "flamegraph"}: Folded stacks of an old trace`,
 	searchColdTrace,
 	mcp.WithTitleAnnotation("Search a cold trace by TraceId"),
 	mcp.WithString("trace_id", mcp.Required(),
@@ -742,18 +820,21 @@ Examples:
 		mcp.Description(`Time duration for cold storage query. Examples: "7d" (last 7 days), "-30m" (last 30 minutes), "2h30m" (last 2.5 hours)`),
 	),
 	mcp.WithString("view",
-		mcp.Enum(ViewFull, ViewSummary, ViewErrorsOnly),
+		mcp.Enum(ViewFull, ViewSummary, ViewErrorsOnly, ViewTree, ViewWaterfall, ViewFlamegraph),
 		mcp.Description(`Specifies the level of detail for cold trace analysis:
 - 'full': (Default) Complete trace with all spans from cold storage
 - 'summary': High-level overview with services, duration, and error count
-- 'errors_only': Only error spans for focused troubleshooting`),
+- 'errors_only': Only error spans for focused troubleshooting
+- 'tree': Span hierarchy across segments with self times and the critical path
+- 'waterfall': Indented text timeline with offsets and durations, repeated sibling spans collapsed
+- 'flamegraph': Folded stacks with the self time in ms, compatible with flame graph tools`),
 	),
 )
 
 // TracesQueryTool is a tool for querying traces with various conditions
-var TracesQueryTool = NewTool[TracesQueryRequest, *mcp.CallToolResult](
+var TracesQueryTool = NewFanOutTool[TracesQueryRequest, *mcp.CallToolResult](
 	"query_traces",
-	`This tool queries traces from SkyWalking OAP based on various conditions and provides intelligent data processing for LLM analysis.
+	fmt.Sprintf(`This tool queries traces from SkyWalking OAP based on various conditions and provides intelligent data processing for LLM analysis.
 
 Workflow:
 1. Use this tool when you need to find traces matching specific criteria
@@ -762,10 +843,10 @@ Workflow:
 4. Choose the appropriate view for your analysis needs
 
 Query Conditions:
-- service_id: Filter by specific service
-- service_instance_id: Filter by specific service instance
+- service_id: Filter by specific service, by ID or name
+- service_instance_id: Filter by specific service instance, by ID or name
 - trace_id: Search for a specific trace ID
-- endpoint_id: Filter by specific endpoint
+- endpoint_id: Filter by specific endpoint, by ID or name
 - duration: Time range for the query (e.g., "1h", "7d", "-30m")
 - min_trace_duration/max_trace_duration: Filter by trace duration in milliseconds
 - trace_state: Filter by trace state (success, error, all)
@@ -773,6 +854,9 @@ Query Conditions:
 - view: Data presentation format (summary, errors_only, full)
 - slow_trace_threshold: Optional threshold for identifying slow traces in milliseconds
 - tags: Filter by span tags (key-value pairs)
+- enrich_traces: Number of top traces whose spans are fetched in summary view
+- max_traces: Collect up to this many traces across pages instead of a single page
+- sampling: How max_traces traces are collected (pages, stratified)
 
 Important Notes:
 - SkyWalking OAP requires either 'duration' or 'trace_id' to be specified
@@ -784,35 +868,52 @@ View Options:
 - 'summary': Intelligent summary with performance metrics and insights
 - 'errors_only': Focused list of error traces for troubleshooting
 
+The summary view reports the p50/p75/p90/p95/p99 durations, a histogram of the durations with
+buckets doubling in size, the count, error rate and p95 of every endpoint, and the outliers: traces
+much slower than the median, found with the median absolute deviation of the durations.
+
+Collecting More Traces:
+- A query returns a single page of traces, 20 by default. Set max_traces to collect up to
+  %d traces from several pages queried concurrently, duplicates are dropped
+- sampling 'pages' (default) takes consecutive pages, i.e. the latest or the slowest traces
+  depending on query_order
+- sampling 'stratified' splits the time window into %d equal slices and takes the same number
+  of traces from each, so that the summary statistics reflect the whole window
+
 Best Practices:
 - Start with 'summary' view to get an intelligent overview
 - Use 'errors_only' view for focused troubleshooting
 - Combine multiple filters for precise results
 - Use duration to limit search scope and improve performance
-- Only set slow_trace_threshold when you need to identify performance issues
+- Check the outliers of the summary first, only set slow_trace_threshold when you know what slow means
 - Use tags to filter traces by specific attributes or metadata
+- Set enrich_traces in summary view to learn the services, span counts and error spans of the
+  top traces, error traces first, then the slowest ones. The trace list of OAP only has endpoints
 
 Examples:
 - {"service_id": "Your_ApplicationName", "duration": "1h", "view": "summary"}: Recent traces summary with performance insights
 - {"trace_state": "error", "duration": "7d", "view": "errors_only"}: Error traces from last week for troubleshooting
 - {"min_trace_duration": 1000, "query_order": "duration_desc", "view": "summary"}: Slow traces analysis with performance metrics
 - {"slow_trace_threshold": 5000, "view": "summary"}: Identify traces slower than 5 seconds
+- {"service_id": "Your_ApplicationName", "duration": "-6h", "view": "summary", "max_traces": 500, "sampling": "stratified"}:
+  Latency distribution over the last 6 hours
+- {"trace_state": "error", "view": "summary", "enrich_traces": 5}: Where the errors of the 5 slowest error traces are
 - {"service_id": "Your_ApplicationName"}: Query with default 1-hour duration
 - {"tags": [{"key": "http.method", "value": "POST"}, {"key": "http.status_code", "value": "500"}], 
-  "duration": "1h"}: Find traces with specific HTTP tags`,
+  "duration": "1h"}: Find traces with specific HTTP tags`, MaxTracesLimit, sampleStrata),
 	searchTraces,
 	mcp.WithTitleAnnotation("Query traces with intelligent analysis"),
 	mcp.WithString("service_id",
-		mcp.Description("Service ID to filter traces. Use this to find traces from a specific service."),
+		mcp.Description("Service ID or name to filter traces. Use this to find traces from a specific service."),
 	),
 	mcp.WithString("service_instance_id",
-		mcp.Description("Service instance ID to filter traces. Use this to find traces from a specific instance."),
+		mcp.Description("Service instance ID or name to filter traces. Names require service_id."),
 	),
 	mcp.WithString("trace_id",
 		mcp.Description("Specific trace ID to search for. Use this when you know the exact trace ID."),
 	),
 	mcp.WithString("endpoint_id",
-		mcp.Description("Endpoint ID to filter traces. Use this to find traces for a specific endpoint."),
+		mcp.Description("Endpoint ID or name to filter traces. Names require service_id."),
 	),
 	mcp.WithString("duration",
 		mcp.Description(`Time duration for the query. Examples: "7d" (last 7 days), "-30m" (last 30 minutes), "2h30m" (last 2.5 hours)`),
@@ -847,6 +948,7 @@ Examples:
 		mcp.Description("Optional threshold for identifying slow traces in milliseconds. "+
 			"Only when this parameter is set will slow traces be included in the summary. "+
 			"Traces with duration exceeding this threshold will be listed in slow_traces. "+
+			"Outliers are detected without it, see outliers in the summary. "+
 			"Examples: 500 (0.5s), 2000 (2s), 5000 (5s)"),
 	),
 	mcp.WithArray("tags",
@@ -856,4 +958,20 @@ Examples: [{"key": "http.method", "value": "POST"}, {"key": "http.status_code",
 	mcp.WithBoolean("cold",
 		mcp.Description("Whether to query from cold-stage storage. Set to true for historical data queries."),
 	),
+	mcp.WithNumber("enrich_traces",
+		mcp.Description(fmt.Sprintf("Summary view only. Number of top traces, error traces first, then the slowest, "+
+			"whose spans are fetched to add their services, span count and error span locations. "+
+			"Defaults to 0, at most %d.", MaxEnrichedTraces)),
+	),
+	mcp.WithNumber("max_traces",
+		mcp.Description(fmt.Sprintf("Collect up to this many traces from several pages, queried concurrently "+
+			"and without duplicates, instead of a single page. page_size sets the size of the pages, "+
+			"defaults to %d. At most %d.", tracesPageSize, MaxTracesLimit)),
+	),
+	mcp.WithString("sampling",
+		mcp.Enum(SamplingPages, SamplingStratified),
+		mcp.Description(`How max_traces traces are collected:
+- 'pages': (Default) Consecutive pages in query_order
+- 'stratified': The same number of traces from equal slices of the time window`),
+	),
 )
@@ -0,0 +1,344 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// maxComparisonItems bounds every list of a trace comparison
+const maxComparisonItems = 20
+
+// Error messages
+const (
+	ErrMissingComparedTraces = "both baseline_trace_id and trace_id must be provided"
+)
+
+// CompareTracesRequest defines the parameters for comparing two traces
+type CompareTracesRequest struct {
+	BaselineTraceID string `json:"baseline_trace_id"`
+	TraceID         string `json:"trace_id"`
+	ColdDuration    string `json:"cold_duration,omitempty"`
+}
+
+// ComparedTrace describes one of the compared traces
+type ComparedTrace struct {
+	TraceID    string `json:"trace_id"`
+	Duration   int64  `json:"duration_ms"`
+	SpanCount  int    `json:"span_count"`
+	ErrorCount int    `json:"error_count"`
+}
+
+// HopDelta is the latency change of a span found in both traces
+type HopDelta struct {
+	Path      string `json:"path"`
+	Baseline  int64  `json:"baseline_ms"`
+	Target    int64  `json:"target_ms"`
+	Delta     int64  `json:"delta_ms"`
+	SelfDelta int64  `json:"self_delta_ms"`
+}
+
+// SpanDiff is a span, or a group of spans with the same path, found in only one of the traces
+type SpanDiff struct {
+	Path     string `json:"path"`
+	Count    int    `json:"count"`
+	Duration int64  `json:"total_duration_ms"`
+}
+
+// TagChange is a tag of a span found in both traces whose value differs
+type TagChange struct {
+	Path     string `json:"path"`
+	Key      string `json:"key"`
+	Baseline string `json:"baseline,omitempty"`
+	Target   string `json:"target,omitempty"`
+}
+
+// TraceComparison is the difference of a trace to a baseline trace of the same endpoint
+type TraceComparison struct {
+	Baseline      *ComparedTrace `json:"baseline"`
+	Target        *ComparedTrace `json:"target"`
+	DurationDelta int64          `json:"duration_delta_ms"`
+	// Hops are the spans found in both traces, largest latency change first
+	Hops []*HopDelta `json:"hops,omitempty"`
+	// Added are the spans only in the target trace, Missing the spans only in the baseline trace
+	Added   []*SpanDiff `json:"added,omitempty"`
+	Missing []*SpanDiff `json:"missing,omitempty"`
+	// NewErrors are the error spans of the target trace that are not errors in the baseline trace
+	NewErrors   []string     `json:"new_errors,omitempty"`
+	ChangedTags []*TagChange `json:"changed_tags,omitempty"`
+	// Omitted counts the items left out of every list
+	Omitted map[string]int `json:"omitted,omitempty"`
+}
+
+// alignedSpan is a span with its position in the trace tree
+type alignedSpan struct {
+	node *SpanNode
+	// path is the chain of entry spans from the root followed by the span, without occurrence indexes
+	path string
+}
+
+// compareTraces fetches two traces and reports the differences of the target trace to the baseline trace
+func compareTraces(ctx context.Context, req *CompareTracesRequest) (*mcp.CallToolResult, error) {
+	if req.BaselineTraceID == "" || req.TraceID == "" {
+		return mcp.NewToolResultError(ErrMissingComparedTraces), nil
+	}
+
+	baseline, _, err := fetchTrace(ctx, req.BaselineTraceID, req.ColdDuration)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	target, _, err := fetchTrace(ctx, req.TraceID, req.ColdDuration)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	comparison := diffTraceTrees(buildTraceTree(req.BaselineTraceID, baseline), buildTraceTree(req.TraceID, target))
+	return toolResultJSON(comparison), nil
+}
+
+// alignSpans indexes the spans of a tree by their service, type and endpoint path from the root.
+// Siblings with the same service, type and endpoint are told apart by their order.
+func alignSpans(tree *TraceTree) (map[string]*alignedSpan, []string) {
+	spans := make(map[string]*alignedSpan)
+	var keys []string
+	// the displayed paths only list the entry spans, where a trace enters a service, to keep them short
+	var walk func(nodes []*SpanNode, parentKey, entryPath string)
+	walk = func(nodes []*SpanNode, parentKey, entryPath string) {
+		occurrences := make(map[string]int)
+		for _, node := range nodes {
+			step := fmt.Sprintf("%s %s %s", node.Service, node.Type, node.Endpoint)
+			n := occurrences[step]
+			occurrences[step]++
+			key := parentKey + " > " + step + "#" + strconv.Itoa(n)
+
+			path, childPath := entryPath, entryPath
+			switch {
+			case entryPath == "" || node.Type == "Entry":
+				childPath = strings.TrimPrefix(entryPath+" > "+node.Service+" "+node.Endpoint, " > ")
+				path = childPath
+			default:
+				path = entryPath + " > " + node.Endpoint
+			}
+			spans[key] = &alignedSpan{node: node, path: path}
+			keys = append(keys, key)
+			walk(node.Children, key, childPath)
+		}
+	}
+	walk(tree.Roots, "", "")
+	return spans, keys
+}
+
+// diffTraceTrees aligns the spans of two trace trees and compares them
+func diffTraceTrees(baselineTree, targetTree *TraceTree) *TraceComparison {
+	comparison := &TraceComparison{
+		Baseline:      comparedTrace(baselineTree),
+		Target:        comparedTrace(targetTree),
+		DurationDelta: targetTree.Duration - baselineTree.Duration,
+	}
+	baselineSpans, baselineKeys := alignSpans(baselineTree)
+	targetSpans, targetKeys := alignSpans(targetTree)
+
+	added := make(map[string]*SpanDiff)
+	var addedPaths []string
+	for _, key := range targetKeys {
+		target := targetSpans[key]
+		baseline, ok := baselineSpans[key]
+		if !ok {
+			addedPaths = addSpanDiff(added, addedPaths, target)
+			if target.node.IsError {
+				comparison.NewErrors = append(comparison.NewErrors, target.path)
+			}
+			continue
+		}
+
+		if delta := target.node.Duration - baseline.node.Duration; delta != 0 {
+			comparison.Hops = append(comparison.Hops, &HopDelta{
+				Path:      target.path,
+				Baseline:  baseline.node.Duration,
+				Target:    target.node.Duration,
+				Delta:     delta,
+				SelfDelta: target.node.SelfTime - baseline.node.SelfTime,
+			})
+		}
+		if target.node.IsError && !baseline.node.IsError {
+			comparison.NewErrors = append(comparison.NewErrors, target.path)
+		}
+		comparison.ChangedTags = append(comparison.ChangedTags, diffTags(target.path, baseline.node.span, target.node.span)...)
+	}
+
+	missing := make(map[string]*SpanDiff)
+	var missingPaths []string
+	for _, key := range baselineKeys {
+		if _, ok := targetSpans[key]; !ok {
+			missingPaths = addSpanDiff(missing, missingPaths, baselineSpans[key])
+		}
+	}
+
+	// among the spans with the same change, the one whose self time changed is the cause of the change
+	sort.SliceStable(comparison.Hops, func(i, j int) bool {
+		a, b := comparison.Hops[i], comparison.Hops[j]
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return abs(a.SelfDelta) > abs(b.SelfDelta)
+	})
+	for _, path := range addedPaths {
+		comparison.Added = append(comparison.Added, added[path])
+	}
+	for _, path := range missingPaths {
+		comparison.Missing = append(comparison.Missing, missing[path])
+	}
+
+	comparison.Hops = truncateComparison(comparison, "hops", comparison.Hops)
+	comparison.Added = truncateComparison(comparison, "added", comparison.Added)
+	comparison.Missing = truncateComparison(comparison, "missing", comparison.Missing)
+	comparison.NewErrors = truncateComparison(comparison, "new_errors", comparison.NewErrors)
+	comparison.ChangedTags = truncateComparison(comparison, "changed_tags", comparison.ChangedTags)
+	return comparison
+}
+
+// comparedTrace describes a compared trace
+func comparedTrace(tree *TraceTree) *ComparedTrace {
+	compared := &ComparedTrace{TraceID: tree.TraceID, Duration: tree.Duration, SpanCount: tree.SpanCount}
+	var count func(nodes []*SpanNode)
+	count = func(nodes []*SpanNode) {
+		for _, node := range nodes {
+			if node.IsError {
+				compared.ErrorCount++
+			}
+			count(node.Children)
+		}
+	}
+	count(tree.Roots)
+	return compared
+}
+
+// addSpanDiff adds a span to the diff of its path, keeping the paths in order of appearance
+func addSpanDiff(diffs map[string]*SpanDiff, paths []string, span *alignedSpan) []string {
+	diff, ok := diffs[span.path]
+	if !ok {
+		diff = &SpanDiff{Path: span.path}
+		diffs[span.path] = diff
+		paths = append(paths, span.path)
+	}
+	diff.Count++
+	diff.Duration += span.node.Duration
+	return paths
+}
+
+// diffTags lists the tags whose value differs between two aligned spans
+func diffTags(path string, baseline, target *api.Span) []*TagChange {
+	baselineTags, targetTags := spanTags(baseline), spanTags(target)
+	keys := make([]string, 0, len(baselineTags)+len(targetTags))
+	for key := range baselineTags {
+		keys = append(keys, key)
+	}
+	for key := range targetTags {
+		if _, ok := baselineTags[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []*TagChange
+	for _, key := range keys {
+		if baselineTags[key] != targetTags[key] {
+			changes = append(changes, &TagChange{Path: path, Key: key, Baseline: baselineTags[key], Target: targetTags[key]})
+		}
+	}
+	return changes
+}
+
+// spanTags returns the tags of a span, the values of repeated keys are joined
+func spanTags(span *api.Span) map[string]string {
+	tags := make(map[string]string, len(span.Tags))
+	for _, tag := range span.Tags {
+		if tag == nil {
+			continue
+		}
+		if value, ok := tags[tag.Key]; ok {
+			tags[tag.Key] = strings.Join([]string{value, stringValue(tag.Value)}, ", ")
+		} else {
+			tags[tag.Key] = stringValue(tag.Value)
+		}
+	}
+	return tags
+}
+
+// truncateComparison keeps the first maxComparisonItems items of a list and counts the others
+func truncateComparison[T any](comparison *TraceComparison, name string, items []T) []T {
+	if len(items) <= maxComparisonItems {
+		return items
+	}
+	if comparison.Omitted == nil {
+		comparison.Omitted = make(map[string]int)
+	}
+	comparison.Omitted[name] = len(items) - maxComparisonItems
+	return items[:maxComparisonItems]
+}
+
+// abs returns the absolute value of a duration
+func abs(d int64) int64 {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// CompareTracesTool is a tool for comparing a trace with a baseline trace
+var CompareTracesTool = NewTool[CompareTracesRequest, *mcp.CallToolResult](
+	"compare_traces",
+	`Compare a slow or failing trace with a healthy baseline trace of the same endpoint.
+
+The spans of both traces are aligned by their path of service, span type and endpoint from the root
+span, repeated siblings by their order, and the differences are reported. Paths list the services
+entered by the trace with their endpoint, followed by the span:
+- duration_delta_ms: the change of the whole trace duration
+- hops: the spans found in both traces whose duration changed, largest change first,
+  with the change of their self time, which tells whether the span itself or its children got slower
+- added / missing: the spans only in the trace / only in the baseline, grouped by path
+- new_errors: the spans failing in the trace but not in the baseline
+- changed_tags: the tags of aligned spans whose value differs, e.g. status codes or SQL statements
+
+Every list is limited to the most relevant items, the number of omitted items is in "omitted".
+
+Examples:
+- {"baseline_trace_id": "good123...", "trace_id": "slow456..."}: Why is this trace slower than usual
+- {"baseline_trace_id": "good123...", "trace_id": "old789..."}: Compare with a trace only in cold storage,
+  searched over the last 1d, 7d and 30d
+- {"baseline_trace_id": "good123...", "trace_id": "old789...", "cold_duration": "90d"}: Also search older cold storage`,
+	compareTraces,
+	mcp.WithTitleAnnotation("Compare two traces"),
+	mcp.WithString("baseline_trace_id", mcp.Required(),
+		mcp.Description("ID of the healthy trace to compare with, e.g. a fast trace of the same endpoint from query_traces."),
+	),
+	mcp.WithString("trace_id", mcp.Required(),
+		mcp.Description("ID of the slow or failing trace to analyze."),
+	),
+	mcp.WithString("cold_duration",
+		mcp.Description(`Time range of the cold storage searched first for the traces not found in hot storage, `+
+			`before the last 1d, 7d and 30d. Examples: "90d", "-2h".`),
+	),
+)
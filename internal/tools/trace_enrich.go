@@ -0,0 +1,176 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// Trace enrichment limits
+const (
+	MaxEnrichedTraces = 20
+	// traceEnrichWorkers bounds the number of traces fetched from OAP at the same time
+	traceEnrichWorkers    = 4
+	maxErrorSpanLocations = 10
+)
+
+// Error constants
+const (
+	ErrInvalidEnrichTraces = "invalid enrich_traces %d, must be between 0 and %d"
+)
+
+// ErrorSpanLocation tells where in a trace an error span is
+type ErrorSpanLocation struct {
+	Service   string `json:"service"`
+	Instance  string `json:"instance,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Type      string `json:"type"`
+	Peer      string `json:"peer,omitempty"`
+	SegmentID string `json:"segment_id"`
+	SpanID    int    `json:"span_id"`
+}
+
+// enrichTracesSummary fetches the spans of the top n traces of the page and adds their services,
+// span counts and error spans to the summary. Error traces come first, then the slowest traces.
+func enrichTracesSummary(ctx context.Context, summary *TracesSummary, traces *api.TraceBrief, n int, coldDuration string) {
+	top := topTraces(traces, n)
+	if len(top) == 0 {
+		return
+	}
+
+	jobs := make(chan *BasicTraceSummary)
+	var wg sync.WaitGroup
+	for range min(traceEnrichWorkers, len(top)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				enrichTrace(ctx, item, coldDuration)
+			}
+		}()
+	}
+	for i := range top {
+		jobs <- &top[i]
+	}
+	close(jobs)
+	wg.Wait()
+
+	services := make(map[string]struct{})
+	enriched := make(map[string]*BasicTraceSummary, len(top))
+	for i := range top {
+		for _, service := range top[i].Services {
+			services[service] = struct{}{}
+		}
+		enriched[top[i].TraceID] = &top[i]
+	}
+	for service := range services {
+		summary.Services = append(summary.Services, service)
+	}
+	sort.Strings(summary.Services)
+
+	// the error, slow and outlier traces show the details of the traces that were enriched
+	for _, list := range [][]BasicTraceSummary{summary.ErrorTraces, summary.SlowTraces, summary.Outliers} {
+		for i := range list {
+			if details, ok := enriched[list[i].TraceID]; ok {
+				list[i] = *details
+			}
+		}
+	}
+	summary.TopTraces = top
+}
+
+// topTraces picks the n traces worth enriching, error traces first, then by duration descending
+func topTraces(traces *api.TraceBrief, n int) []BasicTraceSummary {
+	var items []BasicTraceSummary
+	seen := make(map[string]struct{})
+	for _, traceItem := range traces.Traces {
+		if traceItem == nil || len(traceItem.TraceIds) == 0 {
+			continue
+		}
+		if _, ok := seen[traceItem.TraceIds[0]]; ok {
+			continue
+		}
+		seen[traceItem.TraceIds[0]] = struct{}{}
+
+		startTime, err := time.Parse(TimeFormatFull, traceItem.Start)
+		if err != nil {
+			continue
+		}
+		isError := traceItem.IsError != nil && *traceItem.IsError
+		items = append(items, createBasicTraceSummary(traceItem, startTime.UnixMilli(), int64(traceItem.Duration), isError))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].IsError != items[j].IsError {
+			return items[i].IsError
+		}
+		return items[i].Duration > items[j].Duration
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
+
+// enrichTrace fetches the spans of a trace and fills in its services, span count and error spans.
+// Failures are reported on the trace, so that one missing trace does not fail the summary.
+func enrichTrace(ctx context.Context, item *BasicTraceSummary, coldDuration string) {
+	traceData, _, err := fetchTrace(ctx, item.TraceID, coldDuration)
+	if err != nil {
+		item.EnrichError = err.Error()
+		return
+	}
+
+	tree := buildTraceTree(item.TraceID, traceData)
+	if len(tree.Roots) > 0 {
+		item.ServiceName = tree.Roots[0].Service
+	}
+	item.SpanCount = tree.SpanCount
+
+	services := make(map[string]struct{})
+	for _, span := range traceData.Spans {
+		if span == nil {
+			continue
+		}
+		services[span.ServiceCode] = struct{}{}
+		if span.IsError == nil || !*span.IsError {
+			continue
+		}
+		item.ErrorSpanCount++
+		if len(item.ErrorSpans) < maxErrorSpanLocations {
+			item.ErrorSpans = append(item.ErrorSpans, ErrorSpanLocation{
+				Service:   span.ServiceCode,
+				Instance:  span.ServiceInstanceName,
+				Endpoint:  stringValue(span.EndpointName),
+				Type:      span.Type,
+				Peer:      stringValue(span.Peer),
+				SegmentID: span.SegmentID,
+				SpanID:    span.SpanID,
+			})
+		}
+	}
+	for service := range services {
+		item.Services = append(item.Services, service)
+	}
+	sort.Strings(item.Services)
+}
@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/trace"
+)
+
+// Storage stage constants
+const (
+	StorageStageHot  = "hot"
+	StorageStageCold = "cold"
+)
+
+// DefaultColdTraceLookback is the longest time range searched in cold storage for traces missing from hot storage
+const DefaultColdTraceLookback = 30 * 24 * time.Hour
+
+// Error constants
+const (
+	ErrTraceNotFoundInStorage = "trace with ID '%s' not found in hot storage, nor in cold storage within the last %s"
+)
+
+// coldTraceLookbacks are the time ranges searched in cold storage one after the other, up to the configured lookback
+var coldTraceLookbacks = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// coldTraceLookback is the widest time range searched in cold storage, see SetColdTraceLookback
+var coldTraceLookback = DefaultColdTraceLookback
+
+// SetColdTraceLookback sets the longest time range searched in cold storage by the tools fetching traces by ID,
+// for traces missing from hot storage. Zero disables the automatic search in cold storage.
+func SetColdTraceLookback(lookback time.Duration) {
+	coldTraceLookback = max(lookback, 0)
+}
+
+// traceLookbacks returns the time ranges to search in cold storage, the widest one being the configured lookback
+func traceLookbacks() []time.Duration {
+	var lookbacks []time.Duration
+	for _, lookback := range coldTraceLookbacks {
+		if lookback >= coldTraceLookback {
+			break
+		}
+		lookbacks = append(lookbacks, lookback)
+	}
+	if coldTraceLookback > 0 {
+		lookbacks = append(lookbacks, coldTraceLookback)
+	}
+	return lookbacks
+}
+
+// traceSource tells which storage stage served a trace
+type traceSource struct {
+	Stage string
+	// Lookback is the time range of the cold storage query that found the trace, zero for an explicit time range
+	Lookback time.Duration
+}
+
+func (s traceSource) String() string {
+	if s.Stage == StorageStageCold && s.Lookback > 0 {
+		return fmt.Sprintf("storage stage: %s, found within the last %s", s.Stage, formatLookback(s.Lookback))
+	}
+	return "storage stage: " + s.Stage
+}
+
+// fetchTrace queries a trace from hot storage, then from cold storage until it is found: within coldDuration
+// first when it is set, then over growing time ranges. Without cold storage in OAP, a trace missing from
+// hot storage is reported as not found, other failures of the cold storage queries are reported as they are.
+func fetchTrace(ctx context.Context, traceID, coldDuration string) (*api.Trace, traceSource, error) {
+	traceData, err := trace.Trace(ctx, traceID)
+	if err != nil {
+		return nil, traceSource{}, fmt.Errorf(ErrFailedToQueryTrace, traceID, err)
+	}
+	if len(traceData.Spans) > 0 {
+		return &traceData, traceSource{Stage: StorageStageHot}, nil
+	}
+
+	// a failure within coldDuration is only reported when the growing time ranges do not find the trace either
+	var coldErr error
+	if coldDuration != "" {
+		traceData, err = trace.ColdTrace(ctx, ParseDuration(coldDuration, true), traceID)
+		switch {
+		case err != nil && coldStorageUnsupported(err):
+			return nil, traceSource{}, fmt.Errorf(ErrTraceNotFound, traceID)
+		case err != nil:
+			coldErr = fmt.Errorf(ErrFailedToQueryColdTrace, traceID, err)
+		case len(traceData.Spans) > 0:
+			return &traceData, traceSource{Stage: StorageStageCold}, nil
+		}
+	}
+
+	lookbacks := traceLookbacks()
+	for _, lookback := range lookbacks {
+		traceData, err = trace.ColdTrace(ctx, ParseDuration("-"+lookback.String(), true), traceID)
+		if err != nil {
+			if coldStorageUnsupported(err) {
+				return nil, traceSource{}, fmt.Errorf(ErrTraceNotFound, traceID)
+			}
+			return nil, traceSource{}, fmt.Errorf(ErrFailedToQueryColdTrace, traceID, err)
+		}
+		if len(traceData.Spans) > 0 {
+			return &traceData, traceSource{Stage: StorageStageCold, Lookback: lookback}, nil
+		}
+	}
+
+	switch {
+	case coldErr != nil:
+		return nil, traceSource{}, coldErr
+	case len(lookbacks) == 0:
+		return nil, traceSource{}, fmt.Errorf(ErrTraceNotFound, traceID)
+	default:
+		return nil, traceSource{}, fmt.Errorf(ErrTraceNotFoundInStorage, traceID, formatLookback(lookbacks[len(lookbacks)-1]))
+	}
+}
+
+// coldStorageUnsupported reports whether a cold storage query failed because OAP has no cold storage:
+// OAP before 10.2 does not know the query, and only BanyanDB supports cold storage.
+func coldStorageUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "querytracefromcoldstage") && strings.Contains(msg, "undefined") {
+		return true
+	}
+	return strings.Contains(msg, "cold") && (strings.Contains(msg, "not support") || strings.Contains(msg, "unsupported"))
+}
+
+// formatLookback formats whole days as e.g. "7d", other durations as Go durations
+func formatLookback(lookback time.Duration) string {
+	const day = 24 * time.Hour
+	if lookback%day == 0 {
+		return fmt.Sprintf("%dd", lookback/day)
+	}
+	return lookback.String()
+}
@@ -0,0 +1,227 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/trace"
+)
+
+// Sampling constants
+const (
+	SamplingPages      = "pages"
+	SamplingStratified = "stratified"
+)
+
+// Trace paging limits
+const (
+	MaxTracesLimit = 1000
+	// tracesPageSize is the page size used to collect max_traces traces when page_size is not set
+	tracesPageSize = 100
+	// tracePageWorkers bounds the number of pages queried from OAP at the same time
+	tracePageWorkers = 4
+	// sampleStrata is the number of equal time slices the window is split into by stratified sampling
+	sampleStrata = 10
+)
+
+// Error constants
+const (
+	ErrInvalidMaxTraces         = "invalid max_traces %d, must be between 0 and %d"
+	ErrInvalidSampling          = "invalid sampling '%s', available samplings: %s, %s"
+	ErrSamplingWithoutMaxTraces = "sampling requires max_traces"
+	ErrInvalidQueryDuration     = "invalid query duration %s - %s: %v"
+)
+
+// validateTraceSampling validates the max_traces and sampling parameters
+func validateTraceSampling(req *TracesQueryRequest) error {
+	if req.MaxTraces < 0 || req.MaxTraces > MaxTracesLimit {
+		return fmt.Errorf(ErrInvalidMaxTraces, req.MaxTraces, MaxTracesLimit)
+	}
+	switch req.Sampling {
+	case "", SamplingPages, SamplingStratified:
+	default:
+		return fmt.Errorf(ErrInvalidSampling, req.Sampling, SamplingPages, SamplingStratified)
+	}
+	if req.Sampling != "" && req.MaxTraces == 0 {
+		return errors.New(ErrSamplingWithoutMaxTraces)
+	}
+	return nil
+}
+
+// collectTraces queries up to max_traces traces, either from consecutive pages or sampled evenly
+// across the time window, without duplicates. Without max_traces a single page is queried.
+func collectTraces(ctx context.Context, req *TracesQueryRequest, condition *api.TraceQueryCondition) (api.TraceBrief, error) {
+	if req.MaxTraces == 0 {
+		return trace.Traces(ctx, condition)
+	}
+
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = tracesPageSize
+	}
+
+	var pages [][]*api.BasicTrace
+	var err error
+	if req.Sampling == SamplingStratified && condition.QueryDuration != nil {
+		pages, err = sampleTraces(ctx, condition, pageSize, req.MaxTraces)
+	} else {
+		pages, err = queryTracePages(ctx, condition, max(req.PageNum, 1), pageSize, req.MaxTraces, tracePageWorkers)
+	}
+	if err != nil {
+		return api.TraceBrief{}, err
+	}
+	return api.TraceBrief{Traces: uniqueTraces(pages, req.MaxTraces)}, nil
+}
+
+// queryTracePages queries the pages needed for limit traces from firstPage on, workers pages at a time.
+// It stops after the first page that is not full, there is nothing after it.
+func queryTracePages(ctx context.Context, condition *api.TraceQueryCondition,
+	firstPage, pageSize, limit, workers int) ([][]*api.BasicTrace, error) {
+	pageCount := (limit + pageSize - 1) / pageSize
+	var pages [][]*api.BasicTrace
+	for batchStart := 0; batchStart < pageCount; batchStart += workers {
+		batch := make([][]*api.BasicTrace, min(workers, pageCount-batchStart))
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i := range batch {
+			pageCondition := *condition
+			pageCondition.Paging = BuildPagination(firstPage+batchStart+i, pageSize)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				traces, err := trace.Traces(ctx, &pageCondition)
+				batch[i], errs[i] = traces.Traces, err
+			}()
+		}
+		wg.Wait()
+
+		for i := range batch {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			pages = append(pages, batch[i])
+			if len(batch[i]) < pageSize {
+				return pages, nil
+			}
+		}
+	}
+	return pages, nil
+}
+
+// sampleTraces splits the time window of the condition into equal slices and queries an equal share
+// of the limit from every slice, so that the traces cover the whole window. The latest slice comes first.
+// The shares add up to the limit, so that no slice is cut off when the traces are joined.
+func sampleTraces(ctx context.Context, condition *api.TraceQueryCondition, pageSize, limit int) ([][]*api.BasicTrace, error) {
+	strata, err := traceStrata(condition.QueryDuration, min(sampleStrata, limit))
+	if err != nil {
+		return nil, err
+	}
+	n := len(strata)
+	quota := func(i int) int { return limit*(i+1)/n - limit*i/n }
+
+	results := make([][][]*api.BasicTrace, len(strata))
+	errs := make([]error, len(strata))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(tracePageWorkers, len(strata)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stratumCondition := *condition
+				stratumCondition.QueryDuration = &strata[i]
+				results[i], errs[i] = queryTracePages(ctx, &stratumCondition, 1, min(pageSize, quota(i)), quota(i), 1)
+			}
+		}()
+	}
+	for i := range strata {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var pages [][]*api.BasicTrace
+	for i := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		pages = append(pages, results[i]...)
+	}
+	return pages, nil
+}
+
+// traceStrata splits a duration into n slices with second precision, the latest slice first
+func traceStrata(duration *api.Duration, n int) ([]api.Duration, error) {
+	start, err := parseTimeByStep(duration.Start, duration.Step)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidQueryDuration, duration.Start, duration.End, err)
+	}
+	end, err := parseTimeByStep(duration.End, duration.Step)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidQueryDuration, duration.Start, duration.End, err)
+	}
+	// the end of a duration covers its whole step
+	end = end.Add(stepLength(duration.Step) - time.Second)
+
+	seconds := int64(end.Sub(start)/time.Second) + 1
+	n = int(max(min(int64(n), seconds), 1))
+	strata := make([]api.Duration, n)
+	for i := range strata {
+		sliceStart := start.Add(time.Duration(seconds*int64(i)/int64(n)) * time.Second)
+		sliceEnd := start.Add(time.Duration(seconds*int64(i+1)/int64(n)-1) * time.Second)
+		strata[n-1-i] = api.Duration{
+			Start:     FormatTimeByStep(sliceStart, api.StepSecond),
+			End:       FormatTimeByStep(sliceEnd, api.StepSecond),
+			Step:      api.StepSecond,
+			ColdStage: duration.ColdStage,
+		}
+	}
+	return strata, nil
+}
+
+// uniqueTraces joins pages of traces, dropping the traces already seen, up to limit traces
+func uniqueTraces(pages [][]*api.BasicTrace, limit int) []*api.BasicTrace {
+	traces := make([]*api.BasicTrace, 0, limit)
+	seen := make(map[string]struct{})
+	for _, page := range pages {
+		for _, traceItem := range page {
+			if traceItem == nil {
+				continue
+			}
+			// a trace without trace ID can not be a duplicate
+			if len(traceItem.TraceIds) > 0 {
+				if _, ok := seen[traceItem.TraceIds[0]]; ok {
+					continue
+				}
+				seen[traceItem.TraceIds[0]] = struct{}{}
+			}
+			traces = append(traces, traceItem)
+			if len(traces) == limit {
+				return traces
+			}
+		}
+	}
+	return traces
+}
@@ -0,0 +1,219 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// Outlier detection settings
+const (
+	// outlierZScore is the modified z-score above which a trace is an outlier, as proposed by Iglewicz and Hoaglin
+	outlierZScore = 3.5
+	// madScale makes the median absolute deviation comparable to the standard deviation of a normal distribution
+	madScale = 1.4826
+	// meanADScale does the same for the mean absolute deviation, used when more than half of the durations are equal
+	meanADScale       = 1.253314
+	minOutlierSamples = 8
+	maxOutliers       = 10
+)
+
+// DurationPercentiles are the percentiles of the trace durations
+type DurationPercentiles struct {
+	P50 int64 `json:"p50_ms"`
+	P75 int64 `json:"p75_ms"`
+	P90 int64 `json:"p90_ms"`
+	P95 int64 `json:"p95_ms"`
+	P99 int64 `json:"p99_ms"`
+}
+
+// HistogramBucket counts the traces with a duration in [Min, Max)
+type HistogramBucket struct {
+	Min   int64 `json:"min_ms"`
+	Max   int64 `json:"max_ms"`
+	Count int   `json:"count"`
+}
+
+// EndpointStats breaks the traces of a summary down by endpoint
+type EndpointStats struct {
+	Endpoint   string  `json:"endpoint"`
+	Count      int     `json:"count"`
+	ErrorCount int     `json:"error_count"`
+	ErrorRate  float64 `json:"error_rate"`
+	P95        int64   `json:"p95_ms"`
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// durationPercentiles calculates the percentiles of sorted durations
+func durationPercentiles(sorted []int64) *DurationPercentiles {
+	if len(sorted) == 0 {
+		return nil
+	}
+	return &DurationPercentiles{
+		P50: percentile(sorted, 50),
+		P75: percentile(sorted, 75),
+		P90: percentile(sorted, 90),
+		P95: percentile(sorted, 95),
+		P99: percentile(sorted, 99),
+	}
+}
+
+// durationHistogram counts sorted durations in buckets whose bounds are powers of two,
+// from the bucket of the fastest to the bucket of the slowest trace
+func durationHistogram(sorted []int64) []HistogramBucket {
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	// bucket i holds the durations in [2^(i-1), 2^i), bucket 0 the durations under 1ms
+	bucketOf := func(d int64) int { return bits.Len64(uint64(max(d, 0))) }
+	first, last := bucketOf(sorted[0]), bucketOf(sorted[len(sorted)-1])
+	histogram := make([]HistogramBucket, last-first+1)
+	for i := range histogram {
+		bucket := first + i
+		histogram[i].Max = int64(1) << bucket
+		if bucket > 0 {
+			histogram[i].Min = int64(1) << (bucket - 1)
+		}
+	}
+	for _, d := range sorted {
+		histogram[bucketOf(d)-first].Count++
+	}
+	return histogram
+}
+
+// endpointStatistics breaks the traces down by endpoint, the most frequent endpoints first
+func endpointStatistics(traces *api.TraceBrief) []EndpointStats {
+	durations := make(map[string][]int64)
+	errors := make(map[string]int)
+	for _, traceItem := range traces.Traces {
+		if traceItem == nil {
+			continue
+		}
+		for _, endpoint := range traceItem.EndpointNames {
+			if endpoint == "" {
+				continue
+			}
+			durations[endpoint] = append(durations[endpoint], int64(traceItem.Duration))
+			if traceItem.IsError != nil && *traceItem.IsError {
+				errors[endpoint]++
+			}
+		}
+	}
+
+	stats := make([]EndpointStats, 0, len(durations))
+	for endpoint, values := range durations {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		stats = append(stats, EndpointStats{
+			Endpoint:   endpoint,
+			Count:      len(values),
+			ErrorCount: errors[endpoint],
+			ErrorRate:  math.Round(float64(errors[endpoint])/float64(len(values))*10000) / 10000,
+			P95:        percentile(values, 95),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Endpoint < stats[j].Endpoint
+	})
+	return stats
+}
+
+// median returns the median of sorted values
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// outlierThreshold returns the duration above which a trace is an outlier, based on the median absolute deviation
+// of sorted durations. It returns false when there are too few traces or all durations are equal.
+func outlierThreshold(sorted []int64) (float64, bool) {
+	if len(sorted) < minOutlierSamples {
+		return 0, false
+	}
+
+	values := make([]float64, len(sorted))
+	for i, d := range sorted {
+		values[i] = float64(d)
+	}
+	m := median(values)
+
+	deviations := make([]float64, len(values))
+	var totalDeviation float64
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+		totalDeviation += deviations[i]
+	}
+	sort.Float64s(deviations)
+
+	scale := madScale * median(deviations)
+	if scale == 0 {
+		scale = meanADScale * totalDeviation / float64(len(values))
+	}
+	if scale == 0 {
+		return 0, false
+	}
+	return m + outlierZScore*scale, true
+}
+
+// durationOutliers finds the traces slower than the outlier threshold of sorted durations, slowest first
+func durationOutliers(traces *api.TraceBrief, sorted []int64) (threshold int64, outliers []BasicTraceSummary, count int) {
+	limit, ok := outlierThreshold(sorted)
+	if !ok {
+		return 0, nil, 0
+	}
+
+	for _, traceItem := range traces.Traces {
+		if traceItem == nil || float64(traceItem.Duration) <= limit {
+			continue
+		}
+		startTime, err := time.Parse(TimeFormatFull, traceItem.Start)
+		if err != nil {
+			continue
+		}
+		isError := traceItem.IsError != nil && *traceItem.IsError
+		outliers = append(outliers, createBasicTraceSummary(traceItem, startTime.UnixMilli(), int64(traceItem.Duration), isError))
+	}
+
+	sort.Slice(outliers, func(i, j int) bool {
+		return outliers[i].Duration > outliers[j].Duration
+	})
+	count = len(outliers)
+	if count > maxOutliers {
+		outliers = outliers[:maxOutliers]
+	}
+	return int64(math.Ceil(limit)), outliers, count
+}
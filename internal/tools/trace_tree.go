@@ -0,0 +1,237 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"sort"
+	"strconv"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// SpanNode is a span of a trace tree, with its children in the same and in other segments
+type SpanNode struct {
+	SegmentID string `json:"segment_id"`
+	SpanID    int    `json:"span_id"`
+	Service   string `json:"service"`
+	Instance  string `json:"instance,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Type      string `json:"type"`
+	Component string `json:"component,omitempty"`
+	Peer      string `json:"peer,omitempty"`
+	// Offset is the start of the span relative to the start of the trace
+	Offset   int64 `json:"offset_ms"`
+	Duration int64 `json:"duration_ms"`
+	// SelfTime is the part of the duration not covered by any child
+	SelfTime int64 `json:"self_ms"`
+	IsError  bool  `json:"is_error,omitempty"`
+	// Critical marks the spans on the critical path, the chain of spans that determines the trace duration
+	Critical bool        `json:"critical,omitempty"`
+	Children []*SpanNode `json:"children,omitempty"`
+
+	span *api.Span
+}
+
+// CriticalHop is a span on the critical path of a trace
+type CriticalHop struct {
+	Service  string `json:"service"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Type     string `json:"type"`
+	Duration int64  `json:"duration_ms"`
+	SelfTime int64  `json:"self_ms"`
+}
+
+// TraceTree is the span hierarchy of a trace rebuilt across segments
+type TraceTree struct {
+	TraceID   string `json:"trace_id"`
+	SpanCount int    `json:"span_count"`
+	StartTime int64  `json:"start_time_ms"`
+	Duration  int64  `json:"duration_ms"`
+	// Roots are the spans without a parent, more than one when segments of the trace are missing
+	Roots []*SpanNode `json:"roots"`
+	// CriticalPath lists the spans of the critical path from the root
+	CriticalPath []*CriticalHop `json:"critical_path"`
+	// Bottleneck is the span of the critical path with the most self time
+	Bottleneck *CriticalHop `json:"bottleneck,omitempty"`
+}
+
+// spanKey identifies a span within a trace
+func spanKey(segmentID string, spanID int) string {
+	return segmentID + "/" + strconv.Itoa(spanID)
+}
+
+// buildTraceTree rebuilds the span hierarchy of a trace. Spans are linked to their parent span in the same segment,
+// and the first span of a segment to the span of the parent segment referenced by its refs.
+func buildTraceTree(traceID string, traceData *api.Trace) *TraceTree {
+	tree := &TraceTree{TraceID: traceID}
+	nodes := make(map[string]*SpanNode, len(traceData.Spans))
+	var spans []*api.Span
+	for _, span := range traceData.Spans {
+		if span == nil {
+			continue
+		}
+		spans = append(spans, span)
+		nodes[spanKey(span.SegmentID, span.SpanID)] = newSpanNode(span)
+		if tree.StartTime == 0 || span.StartTime < tree.StartTime {
+			tree.StartTime = span.StartTime
+		}
+	}
+	tree.SpanCount = len(spans)
+
+	var end int64
+	for _, span := range spans {
+		node := nodes[spanKey(span.SegmentID, span.SpanID)]
+		node.Offset = span.StartTime - tree.StartTime
+		end = max(end, span.EndTime)
+
+		if parent := parentNode(span, nodes); parent != nil {
+			parent.Children = append(parent.Children, node)
+		} else {
+			tree.Roots = append(tree.Roots, node)
+		}
+	}
+	tree.Duration = end - tree.StartTime
+
+	sortSpanNodes(tree.Roots)
+	for _, node := range nodes {
+		sortSpanNodes(node.Children)
+		node.SelfTime = selfTime(node)
+	}
+
+	if len(tree.Roots) > 0 {
+		// the root that ends last determines the duration of the trace
+		root := tree.Roots[0]
+		for _, r := range tree.Roots[1:] {
+			if r.span.EndTime > root.span.EndTime {
+				root = r
+			}
+		}
+		markCriticalPath(root, root.span.EndTime)
+		tree.CriticalPath = criticalPath(root, nil)
+		for _, hop := range tree.CriticalPath {
+			if tree.Bottleneck == nil || hop.SelfTime > tree.Bottleneck.SelfTime {
+				tree.Bottleneck = hop
+			}
+		}
+	}
+	return tree
+}
+
+// newSpanNode converts a span returned by OAP
+func newSpanNode(span *api.Span) *SpanNode {
+	return &SpanNode{
+		SegmentID: span.SegmentID,
+		SpanID:    span.SpanID,
+		Service:   span.ServiceCode,
+		Instance:  span.ServiceInstanceName,
+		Endpoint:  stringValue(span.EndpointName),
+		Type:      span.Type,
+		Component: stringValue(span.Component),
+		Peer:      stringValue(span.Peer),
+		Duration:  span.EndTime - span.StartTime,
+		IsError:   span.IsError != nil && *span.IsError,
+		span:      span,
+	}
+}
+
+// parentNode finds the parent of a span, nil for the root span and for spans whose parent segment is missing
+func parentNode(span *api.Span, nodes map[string]*SpanNode) *SpanNode {
+	if span.ParentSpanID >= 0 {
+		return nodes[spanKey(span.SegmentID, span.ParentSpanID)]
+	}
+	for _, ref := range span.Refs {
+		if ref == nil {
+			continue
+		}
+		if parent, ok := nodes[spanKey(ref.ParentSegmentID, ref.ParentSpanID)]; ok {
+			return parent
+		}
+	}
+	return nil
+}
+
+// sortSpanNodes orders sibling spans by start time
+func sortSpanNodes(nodes []*SpanNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].span, nodes[j].span
+		if a.StartTime != b.StartTime {
+			return a.StartTime < b.StartTime
+		}
+		if a.SegmentID != b.SegmentID {
+			return a.SegmentID < b.SegmentID
+		}
+		return a.SpanID < b.SpanID
+	})
+}
+
+// selfTime is the duration of the span minus the union of the time ranges of its children within the span.
+// The children must be sorted by start time.
+func selfTime(node *SpanNode) int64 {
+	start, end := node.span.StartTime, node.span.EndTime
+	covered, cursor := int64(0), start
+	for _, child := range node.Children {
+		childStart, childEnd := max(child.span.StartTime, cursor), min(child.span.EndTime, end)
+		if childEnd > childStart {
+			covered += childEnd - childStart
+			cursor = childEnd
+		}
+	}
+	return max(end-start-covered, 0)
+}
+
+// markCriticalPath marks the span and, from its end backwards, the children that it was waiting for:
+// the child ending last before the cursor is critical, then the cursor moves to its start.
+// Asynchronous children ending after the span are cut at the end of the span.
+func markCriticalPath(node *SpanNode, end int64) {
+	node.Critical = true
+	cursor := min(node.span.EndTime, end)
+
+	children := make([]*SpanNode, len(node.Children))
+	copy(children, node.Children)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].span.EndTime > children[j].span.EndTime
+	})
+	for _, child := range children {
+		if cursor <= node.span.StartTime {
+			break
+		}
+		if child.span.StartTime >= cursor {
+			continue
+		}
+		markCriticalPath(child, cursor)
+		cursor = child.span.StartTime
+	}
+}
+
+// criticalPath lists the critical spans of the subtree in depth-first order
+func criticalPath(node *SpanNode, path []*CriticalHop) []*CriticalHop {
+	if !node.Critical {
+		return path
+	}
+	path = append(path, &CriticalHop{
+		Service:  node.Service,
+		Endpoint: node.Endpoint,
+		Type:     node.Type,
+		Duration: node.Duration,
+		SelfTime: node.SelfTime,
+	})
+	for _, child := range node.Children {
+		path = criticalPath(child, path)
+	}
+	return path
+}
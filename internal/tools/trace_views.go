@@ -0,0 +1,195 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Text rendering settings of the trace views
+const (
+	// waterfallWidth is the number of characters of the timeline of the waterfall view
+	waterfallWidth = 40
+	// collapseMinRepeats is the number of identical consecutive sibling spans collapsed into one line
+	collapseMinRepeats = 3
+)
+
+// spanGroup is a run of identical consecutive sibling spans, or a single span
+type spanGroup struct {
+	spans []*SpanNode
+}
+
+// first returns the span representing the group
+func (g *spanGroup) first() *SpanNode {
+	return g.spans[0]
+}
+
+// spanSignature identifies spans doing the same work, including the work of their children
+func spanSignature(node *SpanNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%s|%s|%t(", node.Service, node.Type, node.Endpoint, node.Peer, node.Component, node.IsError)
+	for _, child := range node.Children {
+		b.WriteString(spanSignature(child))
+		b.WriteString(",")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// groupSiblings collapses runs of at least collapseMinRepeats identical consecutive siblings, such as queries in a loop
+func groupSiblings(nodes []*SpanNode) []*spanGroup {
+	var groups []*spanGroup
+	for i := 0; i < len(nodes); {
+		signature := spanSignature(nodes[i])
+		j := i + 1
+		for j < len(nodes) && spanSignature(nodes[j]) == signature {
+			j++
+		}
+		if j-i >= collapseMinRepeats {
+			groups = append(groups, &spanGroup{spans: nodes[i:j]})
+		} else {
+			for _, node := range nodes[i:j] {
+				groups = append(groups, &spanGroup{spans: []*SpanNode{node}})
+			}
+		}
+		i = j
+	}
+	return groups
+}
+
+// spanTitle describes a span in one line
+func spanTitle(node *SpanNode) string {
+	title := fmt.Sprintf("[%s] %s %s", node.Service, node.Type, node.Endpoint)
+	if node.Peer != "" {
+		title += " -> " + node.Peer
+	}
+	return title
+}
+
+// renderWaterfall renders the trace tree as an indented timeline, one line per span or per group of collapsed spans.
+// Spans on the critical path are marked with "*", error spans with "!".
+func renderWaterfall(tree *TraceTree) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace %s: %d spans, %d ms\n", tree.TraceID, tree.SpanCount, tree.Duration)
+	fmt.Fprintf(&b, "%8s %8s %8s  %-*s  %s\n", "offset", "duration", "self", waterfallWidth+2, "timeline", "span")
+
+	var render func(nodes []*SpanNode, depth int)
+	render = func(nodes []*SpanNode, depth int) {
+		for _, group := range groupSiblings(nodes) {
+			first, last := group.first(), group.spans[len(group.spans)-1]
+			end := last.Offset + last.Duration
+			var self int64
+			for _, node := range group.spans {
+				self += node.SelfTime
+			}
+
+			title := spanTitle(first)
+			if len(group.spans) > 1 {
+				var total int64
+				for _, node := range group.spans {
+					total += node.Duration
+				}
+				title = fmt.Sprintf("%d x %s (avg %d ms)", len(group.spans), title, total/int64(len(group.spans)))
+			}
+			fmt.Fprintf(&b, "%6dms %6dms %6dms  |%s|  %s%s%s\n",
+				first.Offset, end-first.Offset, self, timelineBar(first.Offset, end, tree.Duration),
+				strings.Repeat("  ", depth), spanMarkers(group.spans), title)
+			render(first.Children, depth+1)
+		}
+	}
+	render(tree.Roots, 0)
+	return b.String()
+}
+
+// timelineBar draws the time range of a span relative to the trace duration
+func timelineBar(start, end, duration int64) string {
+	if duration <= 0 {
+		return strings.Repeat("#", waterfallWidth)
+	}
+	from := int(start * waterfallWidth / duration)
+	to := int((end*waterfallWidth + duration - 1) / duration)
+	from = min(max(from, 0), waterfallWidth-1)
+	to = min(max(to, from+1), waterfallWidth)
+	return strings.Repeat(" ", from) + strings.Repeat("#", to-from) + strings.Repeat(" ", waterfallWidth-to)
+}
+
+// spanMarkers marks the critical and the error spans of a group
+func spanMarkers(nodes []*SpanNode) string {
+	critical, isError := false, false
+	for _, node := range nodes {
+		critical = critical || node.Critical
+		isError = isError || node.IsError
+	}
+	markers := ""
+	if critical {
+		markers += "*"
+	}
+	if isError {
+		markers += "!"
+	}
+	if markers != "" {
+		markers += " "
+	}
+	return markers
+}
+
+// renderFlamegraph renders the trace tree as folded stacks, one line per distinct stack with its self time in ms.
+// Identical stacks, such as repeated sibling spans, are merged by summing their self time.
+// The output can be fed to flamegraph.pl, speedscope or inferno.
+func renderFlamegraph(tree *TraceTree) string {
+	selfTimes := make(map[string]int64)
+	var walk func(node *SpanNode, stack string)
+	walk = func(node *SpanNode, stack string) {
+		frame := flameFrame(node)
+		if stack != "" {
+			frame = stack + ";" + frame
+		}
+		selfTimes[frame] += node.SelfTime
+		for _, child := range node.Children {
+			walk(child, frame)
+		}
+	}
+	for _, root := range tree.Roots {
+		walk(root, "")
+	}
+
+	stacks := make([]string, 0, len(selfTimes))
+	for stack, selfTime := range selfTimes {
+		if selfTime > 0 {
+			stacks = append(stacks, stack)
+		}
+	}
+	sort.Strings(stacks)
+
+	var b strings.Builder
+	for _, stack := range stacks {
+		fmt.Fprintf(&b, "%s %d\n", stack, selfTimes[stack])
+	}
+	return b.String()
+}
+
+// flameFrame names the frame of a span, without the separators of the folded format
+func flameFrame(node *SpanNode) string {
+	frame := node.Service + " " + node.Endpoint
+	if node.IsError {
+		frame += " [error]"
+	}
+	return strings.NewReplacer(";", ",", "\n", " ").Replace(frame)
+}